@@ -0,0 +1,166 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Allowance 開立折讓
+func (c *Client) Allowance(req *AllowanceRequest) (*AllowanceResponse, error) {
+	return c.AllowanceContext(context.Background(), req)
+}
+
+// AllowanceContext 開立折讓（可透過 ctx 取消或設定期限）
+func (c *Client) AllowanceContext(ctx context.Context, req *AllowanceRequest) (_ *AllowanceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "Allowance", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 設定商品序號
+	for i := range req.Items {
+		req.Items[i].ItemSeq = i + 1
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/Allowance", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp AllowanceResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析折讓回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// AllowanceByCollegiate 開立線上折讓
+// 回應中的折讓單號為暫定，須待消費者同意後由 AllowanceConsentHandler 收到結果
+func (c *Client) AllowanceByCollegiate(req *AllowanceByCollegiateRequest) (*AllowanceResponse, error) {
+	return c.AllowanceByCollegiateContext(context.Background(), req)
+}
+
+// AllowanceByCollegiateContext 開立線上折讓（可透過 ctx 取消或設定期限）
+func (c *Client) AllowanceByCollegiateContext(ctx context.Context, req *AllowanceByCollegiateRequest) (_ *AllowanceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "AllowanceByCollegiate", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 設定商品序號
+	for i := range req.Items {
+		req.Items[i].ItemSeq = i + 1
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/AllowanceByCollegiate", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp AllowanceResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析線上折讓回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// InvalidAllowance 作廢折讓
+func (c *Client) InvalidAllowance(req *InvalidAllowanceRequest) (*InvalidAllowanceResponse, error) {
+	return c.InvalidAllowanceContext(context.Background(), req)
+}
+
+// InvalidAllowanceContext 作廢折讓（可透過 ctx 取消或設定期限）
+func (c *Client) InvalidAllowanceContext(ctx context.Context, req *InvalidAllowanceRequest) (_ *InvalidAllowanceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "InvalidAllowance", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/AllowanceInvalid", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp InvalidAllowanceResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析作廢折讓回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// CancelAllowance 取消線上折讓（消費者尚未同意前）
+func (c *Client) CancelAllowance(req *CancelAllowanceRequest) (*CancelAllowanceResponse, error) {
+	return c.CancelAllowanceContext(context.Background(), req)
+}
+
+// CancelAllowanceContext 取消線上折讓（可透過 ctx 取消或設定期限）
+func (c *Client) CancelAllowanceContext(ctx context.Context, req *CancelAllowanceRequest) (_ *CancelAllowanceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "CancelAllowance", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/AllowanceInvalidByCollegiate", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp CancelAllowanceResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析取消線上折讓回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
\ No newline at end of file
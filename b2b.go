@@ -0,0 +1,586 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// B2B 電子發票
+//
+// B2B 發票稅額另計：SalesAmount 為未稅銷售額，TaxAmount 為營業稅額，
+// TotalAmount = SalesAmount + TaxAmount。
+// 發票以交換或存證方式傳遞，由交易對象的 ExchangeMode 決定，
+// 可透過 B2BMaintainCustomer 設定；交換模式的發票需由買方確認或退回。
+
+// B2BItem B2B 商品明細（金額皆為未稅）
+type B2BItem struct {
+	ItemSeq    int    `json:"ItemSeq"`
+	ItemName   string `json:"ItemName"`
+	ItemCount  int    `json:"ItemCount"`
+	ItemWord   string `json:"ItemWord"`
+	ItemPrice  int    `json:"ItemPrice"`
+	ItemAmount int    `json:"ItemAmount"`
+	ItemTax    int    `json:"ItemTax,omitempty"`
+	ItemRemark string `json:"ItemRemark,omitempty"`
+}
+
+// B2BMaintainCustomerRequest B2B 交易對象維護請求
+type B2BMaintainCustomerRequest struct {
+	Action          string `json:"Action"` // Add / Update / Delete
+	Identifier      string `json:"Identifier"`
+	CompanyName     string `json:"CompanyName,omitempty"`
+	ExchangeMode    string `json:"ExchangeMode"` // B2BExchangeModeStorage / B2BExchangeModeExchange
+	EmailAddress    string `json:"EmailAddress,omitempty"`
+	TelephoneNumber string `json:"TelephoneNumber,omitempty"`
+	Address         string `json:"Address,omitempty"`
+	ContactName     string `json:"ContactName,omitempty"`
+}
+
+// Validate 驗證 B2B 交易對象維護請求
+func (r *B2BMaintainCustomerRequest) Validate() error {
+	switch r.Action {
+	case "Add", "Update", "Delete":
+	default:
+		return NewError(ErrCodeValidation, "Action 不正確")
+	}
+	
+	if !ValidateTaxID(r.Identifier) {
+		return NewError(ErrCodeValidation, "統一編號格式不正確")
+	}
+	
+	if r.ExchangeMode != B2BExchangeModeStorage && r.ExchangeMode != B2BExchangeModeExchange {
+		return NewError(ErrCodeValidation, "ExchangeMode 不正確")
+	}
+	
+	if r.EmailAddress != "" && !emailRegex.MatchString(r.EmailAddress) {
+		return NewError(ErrCodeValidation, "Email 格式不正確")
+	}
+	
+	return nil
+}
+
+// B2BMaintainCustomerResponse B2B 交易對象維護回應
+type B2BMaintainCustomerResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+}
+
+// B2BIssueRequest B2B 開立發票請求
+type B2BIssueRequest struct {
+	RelateNumber       string `json:"RelateNumber"`
+	CustomerIdentifier string `json:"CustomerIdentifier"` // 買方統一編號（必填）
+	CustomerEmail      string `json:"CustomerEmail,omitempty"`
+	ClearanceMark      string `json:"ClearanceMark,omitempty"`
+	InvType            string `json:"InvType"`
+	TaxType            string `json:"TaxType"`
+	
+	// 金額
+	SalesAmount int `json:"SalesAmount"` // 未稅銷售額
+	TaxAmount   int `json:"TaxAmount"`   // 營業稅額
+	TotalAmount int `json:"TotalAmount"` // 含稅總計
+	
+	Items         []B2BItem `json:"Items"`
+	InvoiceRemark string    `json:"InvoiceRemark,omitempty"`
+}
+
+// Validate 驗證 B2B 開立發票請求
+func (r *B2BIssueRequest) Validate() error {
+	if r.RelateNumber == "" {
+		return NewError(ErrCodeValidation, "RelateNumber 不能為空")
+	}
+	
+	if len(r.RelateNumber) > 30 {
+		return NewError(ErrCodeValidation, "RelateNumber 長度不能超過 30")
+	}
+	
+	if r.CustomerIdentifier == "" {
+		return NewError(ErrCodeValidation, "CustomerIdentifier 不能為空")
+	}
+	
+	if !ValidateTaxID(r.CustomerIdentifier) {
+		return NewError(ErrCodeValidation, "統一編號格式不正確")
+	}
+	
+	if r.CustomerEmail != "" && !emailRegex.MatchString(r.CustomerEmail) {
+		return NewError(ErrCodeValidation, "Email 格式不正確")
+	}
+	
+	if r.InvType != InvTypeGeneral && r.InvType != InvTypeSpecial {
+		return NewError(ErrCodeValidation, "InvType 不正確")
+	}
+	
+	if len(r.Items) == 0 {
+		return NewError(ErrCodeValidation, "商品明細不能為空")
+	}
+	
+	totalAmount := 0
+	for _, item := range r.Items {
+		totalAmount += item.ItemAmount
+	}
+	
+	if totalAmount != r.SalesAmount {
+		return NewError(ErrCodeValidation,
+			fmt.Sprintf("銷售額不一致: 預期 %d, 實際 %d", totalAmount, r.SalesAmount))
+	}
+	
+	return validateB2BTax(r.TaxType, r.SalesAmount, r.TaxAmount, r.TotalAmount)
+}
+
+// validateB2BTax 驗證 B2B 稅額與總計
+func validateB2BTax(taxType string, salesAmount, taxAmount, totalAmount int) error {
+	switch taxType {
+	case TaxTypeRegular:
+		// 營業稅 5%，四捨五入
+		expected := int(math.Round(float64(salesAmount) * 0.05))
+		if taxAmount != expected {
+			return NewError(ErrCodeValidation,
+				fmt.Sprintf("稅額不一致: 預期 %d, 實際 %d", expected, taxAmount))
+		}
+	case TaxTypeZero, TaxTypeFree:
+		if taxAmount != 0 {
+			return NewError(ErrCodeValidation, "零稅率或免稅發票稅額必須為 0")
+		}
+	case TaxTypeSpecial, TaxTypeMixed:
+	default:
+		return NewError(ErrCodeValidation, "TaxType 不正確")
+	}
+	
+	if salesAmount+taxAmount != totalAmount {
+		return NewError(ErrCodeValidation,
+			fmt.Sprintf("總計不一致: 預期 %d, 實際 %d", salesAmount+taxAmount, totalAmount))
+	}
+	
+	return nil
+}
+
+// B2BIssueResponse B2B 開立發票回應
+type B2BIssueResponse struct {
+	RtnCode       int    `json:"RtnCode"`
+	RtnMsg        string `json:"RtnMsg"`
+	InvoiceNumber string `json:"InvoiceNumber"`
+	InvoiceDate   string `json:"InvoiceDate"`
+	RandomNumber  string `json:"RandomNumber"`
+}
+
+// B2BInvalidRequest B2B 作廢發票請求
+type B2BInvalidRequest struct {
+	InvoiceNumber string `json:"InvoiceNumber"`
+	InvoiceDate   string `json:"InvoiceDate"` // yyyy-MM-dd
+	Reason        string `json:"Reason"`
+	Remark        string `json:"Remark,omitempty"`
+}
+
+// Validate 驗證 B2B 作廢發票請求
+func (r *B2BInvalidRequest) Validate() error {
+	if !invoiceNoRegex.MatchString(r.InvoiceNumber) {
+		return NewError(ErrCodeValidation, "InvoiceNumber 格式不正確")
+	}
+	
+	if r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "InvoiceDate 不能為空")
+	}
+	
+	return validateReason(r.Reason)
+}
+
+// B2BInvalidResponse B2B 作廢發票回應
+type B2BInvalidResponse struct {
+	RtnCode       int    `json:"RtnCode"`
+	RtnMsg        string `json:"RtnMsg"`
+	InvoiceNumber string `json:"InvoiceNumber"`
+}
+
+// B2BAllowanceItem B2B 折讓明細，須對應原發票明細
+type B2BAllowanceItem struct {
+	OriginalInvoiceNumber  string `json:"OriginalInvoiceNumber"`
+	OriginalInvoiceDate    string `json:"OriginalInvoiceDate"` // yyyy-MM-dd
+	OriginalSequenceNumber int    `json:"OriginalSequenceNumber"`
+	ItemName               string `json:"ItemName"`
+	ItemCount              int    `json:"ItemCount"`
+	ItemWord               string `json:"ItemWord"`
+	ItemPrice              int    `json:"ItemPrice"`
+	ItemAmount             int    `json:"ItemAmount"` // 未稅
+	ItemTax                int    `json:"ItemTax"`
+}
+
+// B2BAllowanceRequest B2B 開立折讓請求
+type B2BAllowanceRequest struct {
+	CustomerIdentifier string             `json:"CustomerIdentifier"`
+	CustomerEmail      string             `json:"CustomerEmail,omitempty"`
+	TaxAmount          int                `json:"TaxAmount"`
+	TotalAmount        int                `json:"TotalAmount"` // 未稅折讓金額合計
+	Items              []B2BAllowanceItem `json:"Items"`
+}
+
+// Validate 驗證 B2B 開立折讓請求
+func (r *B2BAllowanceRequest) Validate() error {
+	if r.CustomerIdentifier == "" {
+		return NewError(ErrCodeValidation, "CustomerIdentifier 不能為空")
+	}
+	
+	if !ValidateTaxID(r.CustomerIdentifier) {
+		return NewError(ErrCodeValidation, "統一編號格式不正確")
+	}
+	
+	if r.CustomerEmail != "" && !emailRegex.MatchString(r.CustomerEmail) {
+		return NewError(ErrCodeValidation, "Email 格式不正確")
+	}
+	
+	if len(r.Items) == 0 {
+		return NewError(ErrCodeValidation, "折讓明細不能為空")
+	}
+	
+	totalAmount, totalTax := 0, 0
+	for _, item := range r.Items {
+		if !invoiceNoRegex.MatchString(item.OriginalInvoiceNumber) {
+			return NewError(ErrCodeValidation, "OriginalInvoiceNumber 格式不正確")
+		}
+		if item.OriginalInvoiceDate == "" {
+			return NewError(ErrCodeValidation, "OriginalInvoiceDate 不能為空")
+		}
+		totalAmount += item.ItemAmount
+		totalTax += item.ItemTax
+	}
+	
+	if totalAmount != r.TotalAmount {
+		return NewError(ErrCodeValidation,
+			fmt.Sprintf("折讓金額不一致: 預期 %d, 實際 %d", totalAmount, r.TotalAmount))
+	}
+	
+	if totalTax != r.TaxAmount {
+		return NewError(ErrCodeValidation,
+			fmt.Sprintf("折讓稅額不一致: 預期 %d, 實際 %d", totalTax, r.TaxAmount))
+	}
+	
+	return nil
+}
+
+// B2BAllowanceResponse B2B 開立折讓回應
+type B2BAllowanceResponse struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	AllowanceNo string `json:"AllowanceNo"`
+}
+
+// B2BGetIssueRequest B2B 查詢發票請求
+type B2BGetIssueRequest struct {
+	InvoiceCategory int    `json:"InvoiceCategory"` // B2BInvoiceCategorySales / B2BInvoiceCategoryPurchase
+	InvoiceNumber   string `json:"InvoiceNumber"`
+	InvoiceDate     string `json:"InvoiceDate"` // yyyy-MM-dd
+	RelateNumber    string `json:"RelateNumber,omitempty"`
+}
+
+// Validate 驗證 B2B 查詢發票請求
+func (r *B2BGetIssueRequest) Validate() error {
+	if r.InvoiceCategory != B2BInvoiceCategorySales && r.InvoiceCategory != B2BInvoiceCategoryPurchase {
+		return NewError(ErrCodeValidation, "InvoiceCategory 不正確")
+	}
+	
+	if !invoiceNoRegex.MatchString(r.InvoiceNumber) {
+		return NewError(ErrCodeValidation, "InvoiceNumber 格式不正確")
+	}
+	
+	if r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "InvoiceDate 不能為空")
+	}
+	
+	return nil
+}
+
+// B2BInvoice B2B 發票資料
+type B2BInvoice struct {
+	InvoiceNumber      string
+	InvoiceDate        time.Time
+	RelateNumber       string
+	RandomNumber       string
+	SellerIdentifier   string
+	CustomerIdentifier string
+	InvType            string
+	TaxType            string
+	SalesAmount        int
+	TaxAmount          int
+	TotalAmount        int
+	Status             string // InvoiceStatusNormal / InvoiceStatusInvalid
+	UploadStatus       string // UploadStatusYes / UploadStatusNo
+	ExchangeMode       string // B2BExchangeModeStorage / B2BExchangeModeExchange
+	ExchangeStatus     string // 交換模式下的確認狀態 B2BExchangeStatus*
+	Remark             string
+	Items              []B2BItem
+}
+
+// IsInvalid 發票是否已作廢
+func (inv *B2BInvoice) IsInvalid() bool {
+	return inv.Status == InvoiceStatusInvalid
+}
+
+// IsPending 交換模式的發票是否仍待對方確認
+func (inv *B2BInvoice) IsPending() bool {
+	return inv.ExchangeMode == B2BExchangeModeExchange && inv.ExchangeStatus == B2BExchangeStatusPending
+}
+
+// b2bInvoiceData B2B 查詢發票回應資料
+type b2bInvoiceData struct {
+	RtnCode            int        `json:"RtnCode"`
+	RtnMsg             string     `json:"RtnMsg"`
+	InvoiceNumber      string     `json:"InvoiceNumber"`
+	InvoiceDate        string     `json:"InvoiceDate"`
+	RelateNumber       string     `json:"RelateNumber"`
+	RandomNumber       string     `json:"RandomNumber"`
+	SellerIdentifier   string     `json:"SellerIdentifier"`
+	CustomerIdentifier string     `json:"CustomerIdentifier"`
+	InvType            string     `json:"InvType"`
+	TaxType            string     `json:"TaxType"`
+	SalesAmount        flexNumber `json:"SalesAmount"`
+	TaxAmount          flexNumber `json:"TaxAmount"`
+	TotalAmount        flexNumber `json:"TotalAmount"`
+	InvalidStatus      string     `json:"Invalid_Status"`
+	UploadStatus       string     `json:"Upload_Status"`
+	ExchangeMode       string     `json:"ExchangeMode"`
+	ExchangeStatus     string     `json:"ExchangeStatus"`
+	InvoiceRemark      string     `json:"InvoiceRemark"`
+	Items              []struct {
+		ItemSeq    flexNumber `json:"ItemSeq"`
+		ItemName   string     `json:"ItemName"`
+		ItemCount  flexNumber `json:"ItemCount"`
+		ItemWord   string     `json:"ItemWord"`
+		ItemPrice  flexNumber `json:"ItemPrice"`
+		ItemAmount flexNumber `json:"ItemAmount"`
+		ItemTax    flexNumber `json:"ItemTax"`
+		ItemRemark string     `json:"ItemRemark"`
+	} `json:"Items"`
+}
+
+// toB2BInvoice 轉換為 B2BInvoice
+func (d *b2bInvoiceData) toB2BInvoice() (*B2BInvoice, error) {
+	invoiceDate, err := parseOptionalDate(d.InvoiceDate)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("發票日期格式錯誤: %s", d.InvoiceDate))
+	}
+	
+	inv := &B2BInvoice{
+		InvoiceNumber:      d.InvoiceNumber,
+		InvoiceDate:        invoiceDate,
+		RelateNumber:       d.RelateNumber,
+		RandomNumber:       d.RandomNumber,
+		SellerIdentifier:   d.SellerIdentifier,
+		CustomerIdentifier: d.CustomerIdentifier,
+		InvType:            d.InvType,
+		TaxType:            d.TaxType,
+		SalesAmount:        d.SalesAmount.Int(),
+		TaxAmount:          d.TaxAmount.Int(),
+		TotalAmount:        d.TotalAmount.Int(),
+		Status:             invalidStatus(d.InvalidStatus),
+		UploadStatus:       uploadStatus(d.UploadStatus),
+		ExchangeMode:       d.ExchangeMode,
+		ExchangeStatus:     d.ExchangeStatus,
+		Remark:             d.InvoiceRemark,
+	}
+	
+	for _, item := range d.Items {
+		inv.Items = append(inv.Items, B2BItem{
+			ItemSeq:    item.ItemSeq.Int(),
+			ItemName:   item.ItemName,
+			ItemCount:  item.ItemCount.Int(),
+			ItemWord:   item.ItemWord,
+			ItemPrice:  item.ItemPrice.Int(),
+			ItemAmount: item.ItemAmount.Int(),
+			ItemTax:    item.ItemTax.Int(),
+			ItemRemark: item.ItemRemark,
+		})
+	}
+	
+	return inv, nil
+}
+
+// B2BMaintainCustomer B2B 交易對象維護，設定交易對象的發票傳遞模式
+func (c *Client) B2BMaintainCustomer(req *B2BMaintainCustomerRequest) (*B2BMaintainCustomerResponse, error) {
+	return c.B2BMaintainCustomerContext(context.Background(), req)
+}
+
+// B2BMaintainCustomerContext B2B 交易對象維護（可透過 ctx 取消或設定期限）
+func (c *Client) B2BMaintainCustomerContext(ctx context.Context, req *B2BMaintainCustomerRequest) (_ *B2BMaintainCustomerResponse, err error) {
+	ctx, span := c.startMethod(ctx, "B2BMaintainCustomer", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2BInvoice/MaintainMerchantCustomerData", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp B2BMaintainCustomerResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析交易對象維護回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// B2BIssue B2B 開立發票
+func (c *Client) B2BIssue(req *B2BIssueRequest) (*B2BIssueResponse, error) {
+	return c.B2BIssueContext(context.Background(), req)
+}
+
+// B2BIssueContext B2B 開立發票（可透過 ctx 取消或設定期限）
+func (c *Client) B2BIssueContext(ctx context.Context, req *B2BIssueRequest) (_ *B2BIssueResponse, err error) {
+	ctx, span := c.startMethod(ctx, "B2BIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 設定商品序號
+	for i := range req.Items {
+		req.Items[i].ItemSeq = i + 1
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2BInvoice/Issue", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp B2BIssueResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析 B2B 發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// B2BInvalid B2B 作廢發票
+func (c *Client) B2BInvalid(req *B2BInvalidRequest) (*B2BInvalidResponse, error) {
+	return c.B2BInvalidContext(context.Background(), req)
+}
+
+// B2BInvalidContext B2B 作廢發票（可透過 ctx 取消或設定期限）
+func (c *Client) B2BInvalidContext(ctx context.Context, req *B2BInvalidRequest) (_ *B2BInvalidResponse, err error) {
+	ctx, span := c.startMethod(ctx, "B2BInvalid", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2BInvoice/Invalid", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp B2BInvalidResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析 B2B 作廢回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// B2BAllowance B2B 開立折讓
+func (c *Client) B2BAllowance(req *B2BAllowanceRequest) (*B2BAllowanceResponse, error) {
+	return c.B2BAllowanceContext(context.Background(), req)
+}
+
+// B2BAllowanceContext B2B 開立折讓（可透過 ctx 取消或設定期限）
+func (c *Client) B2BAllowanceContext(ctx context.Context, req *B2BAllowanceRequest) (_ *B2BAllowanceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "B2BAllowance", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2BInvoice/Allowance", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp B2BAllowanceResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析 B2B 折讓回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// B2BGetIssue B2B 查詢發票
+func (c *Client) B2BGetIssue(req *B2BGetIssueRequest) (*B2BInvoice, error) {
+	return c.B2BGetIssueContext(context.Background(), req)
+}
+
+// B2BGetIssueContext B2B 查詢發票（可透過 ctx 取消或設定期限）
+func (c *Client) B2BGetIssueContext(ctx context.Context, req *B2BGetIssueRequest) (_ *B2BInvoice, err error) {
+	ctx, span := c.startMethod(ctx, "B2BGetIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2BInvoice/GetIssue", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp b2bInvoiceData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析 B2B 查詢發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return resp.toB2BInvoice()
+}
\ No newline at end of file
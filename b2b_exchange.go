@@ -0,0 +1,317 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// B2BConfirmKind B2B 交換模式確認類別
+type B2BConfirmKind string
+
+const (
+	B2BConfirmIssue            B2BConfirmKind = "Issue"            // 發票確認
+	B2BConfirmInvalid          B2BConfirmKind = "Invalid"          // 作廢確認
+	B2BConfirmReject           B2BConfirmKind = "Reject"           // 退回確認
+	B2BConfirmAllowance        B2BConfirmKind = "Allowance"        // 折讓確認
+	B2BConfirmAllowanceInvalid B2BConfirmKind = "AllowanceInvalid" // 作廢折讓確認
+)
+
+// b2bConfirmPaths 確認類別對應的 API 路徑
+var b2bConfirmPaths = map[B2BConfirmKind]string{
+	B2BConfirmIssue:            "/B2BInvoice/IssueConfirm",
+	B2BConfirmInvalid:          "/B2BInvoice/InvalidConfirm",
+	B2BConfirmReject:           "/B2BInvoice/RejectConfirm",
+	B2BConfirmAllowance:        "/B2BInvoice/AllowanceConfirm",
+	B2BConfirmAllowanceInvalid: "/B2BInvoice/AllowanceInvalidConfirm",
+}
+
+// B2BRejectKind B2B 交換模式退回類別
+type B2BRejectKind string
+
+const (
+	B2BRejectIssue B2BRejectKind = "Issue" // 退回發票
+)
+
+// b2bRejectPaths 退回類別對應的 API 路徑
+var b2bRejectPaths = map[B2BRejectKind]string{
+	B2BRejectIssue: "/B2BInvoice/Reject",
+}
+
+// B2BConfirmRequest B2B 交換模式確認請求
+// 發票相關確認需填 InvoiceNumber 與 InvoiceDate，折讓相關確認需填 AllowanceNo
+type B2BConfirmRequest struct {
+	Kind          B2BConfirmKind `json:"-"`
+	InvoiceNumber string         `json:"InvoiceNumber,omitempty"`
+	InvoiceDate   string         `json:"InvoiceDate,omitempty"` // yyyy-MM-dd
+	AllowanceNo   string         `json:"AllowanceNo,omitempty"`
+	Remark        string         `json:"Remark,omitempty"`
+}
+
+// Validate 驗證 B2B 交換模式確認請求
+func (r *B2BConfirmRequest) Validate() error {
+	switch r.Kind {
+	case B2BConfirmIssue, B2BConfirmInvalid, B2BConfirmReject:
+		if err := validateExchangeInvoice(r.InvoiceNumber, r.InvoiceDate); err != nil {
+			return err
+		}
+	case B2BConfirmAllowance, B2BConfirmAllowanceInvalid:
+		if !allowanceNoRegex.MatchString(r.AllowanceNo) {
+			return NewError(ErrCodeValidation, "AllowanceNo 格式不正確")
+		}
+	default:
+		return NewError(ErrCodeValidation, "確認類別不正確")
+	}
+	
+	if len(r.Remark) > 200 {
+		return NewError(ErrCodeValidation, "Remark 長度不能超過 200")
+	}
+	
+	return nil
+}
+
+// B2BRejectRequest B2B 交換模式退回請求
+// 退回發票需填 InvoiceNumber 與 InvoiceDate
+type B2BRejectRequest struct {
+	Kind          B2BRejectKind `json:"-"`
+	InvoiceNumber string        `json:"InvoiceNumber,omitempty"`
+	InvoiceDate   string        `json:"InvoiceDate,omitempty"` // yyyy-MM-dd
+	Reason        string        `json:"Reason"`
+	Remark        string        `json:"Remark,omitempty"`
+}
+
+// Validate 驗證 B2B 退回請求
+func (r *B2BRejectRequest) Validate() error {
+	switch r.Kind {
+	case B2BRejectIssue:
+		if err := validateExchangeInvoice(r.InvoiceNumber, r.InvoiceDate); err != nil {
+			return err
+		}
+	default:
+		return NewError(ErrCodeValidation, "退回類別不正確")
+	}
+	
+	if len(r.Remark) > 200 {
+		return NewError(ErrCodeValidation, "Remark 長度不能超過 200")
+	}
+	
+	return validateReason(r.Reason)
+}
+
+// validateExchangeInvoice 驗證交換模式的發票號碼與日期
+func validateExchangeInvoice(invoiceNumber, invoiceDate string) error {
+	if !invoiceNoRegex.MatchString(invoiceNumber) {
+		return NewError(ErrCodeValidation, "InvoiceNumber 格式不正確")
+	}
+	
+	if invoiceDate == "" {
+		return NewError(ErrCodeValidation, "InvoiceDate 不能為空")
+	}
+	
+	return nil
+}
+
+// B2BExchangeResponse B2B 交換模式確認/退回回應
+type B2BExchangeResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+}
+
+// B2BConfirm B2B 交換模式確認（發票、作廢、退回、折讓、作廢折讓）
+func (c *Client) B2BConfirm(req *B2BConfirmRequest) (*B2BExchangeResponse, error) {
+	return c.B2BConfirmContext(context.Background(), req)
+}
+
+// B2BConfirmContext B2B 交換模式確認（可透過 ctx 取消或設定期限）
+func (c *Client) B2BConfirmContext(ctx context.Context, req *B2BConfirmRequest) (_ *B2BExchangeResponse, err error) {
+	ctx, span := c.startMethod(ctx, "B2BConfirm", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	return c.sendExchange(ctx, b2bConfirmPaths[req.Kind], req)
+}
+
+// B2BReject B2B 交換模式退回發票
+func (c *Client) B2BReject(req *B2BRejectRequest) (*B2BExchangeResponse, error) {
+	return c.B2BRejectContext(context.Background(), req)
+}
+
+// B2BRejectContext B2B 交換模式退回（可透過 ctx 取消或設定期限）
+func (c *Client) B2BRejectContext(ctx context.Context, req *B2BRejectRequest) (_ *B2BExchangeResponse, err error) {
+	ctx, span := c.startMethod(ctx, "B2BReject", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	return c.sendExchange(ctx, b2bRejectPaths[req.Kind], req)
+}
+
+// sendExchange 發送交換模式確認/退回請求
+func (c *Client) sendExchange(ctx context.Context, apiPath string, req interface{}) (*B2BExchangeResponse, error) {
+	// 發送請求
+	respData, err := c.sendRequest(ctx, apiPath, req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp B2BExchangeResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析 B2B 交換回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// B2BExchangeFilter B2B 交換模式發票查詢條件
+type B2BExchangeFilter struct {
+	InvoiceCategory int       // B2BInvoiceCategorySales（我方開出）/ B2BInvoiceCategoryPurchase（供應商開給我方）
+	BeginDate       time.Time // 查詢起始日期（含）
+	EndDate         time.Time // 查詢結束日期（含）
+	ExchangeStatus  string    // B2BExchangeStatus*，空字串表示不限
+	PageSize        int       // 每頁筆數，預設 200，上限 200
+}
+
+// Validate 驗證 B2B 交換模式發票查詢條件
+func (f *B2BExchangeFilter) Validate() error {
+	if f.InvoiceCategory != B2BInvoiceCategorySales && f.InvoiceCategory != B2BInvoiceCategoryPurchase {
+		return NewError(ErrCodeValidation, "InvoiceCategory 不正確")
+	}
+	
+	if f.BeginDate.IsZero() || f.EndDate.IsZero() {
+		return NewError(ErrCodeValidation, "BeginDate 與 EndDate 不能為空")
+	}
+	
+	if f.EndDate.Before(f.BeginDate) {
+		return NewError(ErrCodeValidation, "EndDate 不能早於 BeginDate")
+	}
+	
+	switch f.ExchangeStatus {
+	case "", B2BExchangeStatusPending, B2BExchangeStatusConfirmed, B2BExchangeStatusRejected:
+	default:
+		return NewError(ErrCodeValidation, "ExchangeStatus 不正確")
+	}
+	
+	if f.PageSize < 0 || f.PageSize > 200 {
+		return NewError(ErrCodeValidation, "PageSize 必須介於 0~200（0 表示預設）")
+	}
+	
+	return nil
+}
+
+// b2bIssueListRequest B2B 查詢發票清單請求
+type b2bIssueListRequest struct {
+	InvoiceCategory int    `json:"InvoiceCategory"`
+	BeginDate       string `json:"BeginDate"`
+	EndDate         string `json:"EndDate"`
+	ExchangeMode    string `json:"ExchangeMode"`
+	ExchangeStatus  string `json:"ExchangeStatus,omitempty"`
+	NumPerPage      int    `json:"NumPerPage"`
+	ShowingPage     int    `json:"ShowingPage"`
+}
+
+// b2bInvoiceListData B2B 查詢發票清單回應資料
+type b2bInvoiceListData struct {
+	RtnCode     int              `json:"RtnCode"`
+	RtnMsg      string           `json:"RtnMsg"`
+	TotalCount  int              `json:"TotalCount"`
+	InvoiceData []b2bInvoiceData `json:"InvoiceData"`
+}
+
+// B2BListExchange 查詢交換模式發票及其確認狀態
+// 以 ExchangeStatus 為 B2BExchangeStatusPending 查詢即為待確認清單；
+// 迭代方式與 ListInvoices 相同
+func (c *Client) B2BListExchange(ctx context.Context, filter *B2BExchangeFilter) iter.Seq2[B2BInvoice, error] {
+	return func(yield func(B2BInvoice, error) bool) {
+		ctx, span := c.startMethod(ctx, "B2BListExchange", nil)
+		defer span.End()
+		
+		// 驗證請求
+		if err := filter.Validate(); err != nil {
+			recordError(span, err)
+			yield(B2BInvoice{}, err)
+			return
+		}
+		
+		req := b2bIssueListRequest{
+			InvoiceCategory: filter.InvoiceCategory,
+			BeginDate:       FormatInvoiceDate(filter.BeginDate),
+			EndDate:         FormatInvoiceDate(filter.EndDate),
+			ExchangeMode:    B2BExchangeModeExchange,
+			ExchangeStatus:  filter.ExchangeStatus,
+			NumPerPage:      filter.PageSize,
+		}
+		if req.NumPerPage == 0 {
+			req.NumPerPage = 200
+		}
+		
+		fetched := 0
+		for page := 1; ; page++ {
+			req.ShowingPage = page
+			resp, err := c.b2bIssueListPage(ctx, &req)
+			if err != nil {
+				recordError(span, err)
+				yield(B2BInvoice{}, err)
+				return
+			}
+			
+			for i := range resp.InvoiceData {
+				inv, err := resp.InvoiceData[i].toB2BInvoice()
+				if err != nil {
+					recordError(span, err)
+					yield(B2BInvoice{}, err)
+					return
+				}
+				
+				if !yield(*inv, nil) {
+					return
+				}
+			}
+			
+			fetched += len(resp.InvoiceData)
+			if len(resp.InvoiceData) == 0 || fetched >= resp.TotalCount {
+				return
+			}
+		}
+	}
+}
+
+// b2bIssueListPage B2B 查詢發票清單單頁資料
+func (c *Client) b2bIssueListPage(ctx context.Context, req *b2bIssueListRequest) (*b2bInvoiceListData, error) {
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2BInvoice/GetIssueList", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp b2bInvoiceListData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析 B2B 發票清單回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
\ No newline at end of file
@@ -0,0 +1,154 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// maxCallbackBodySize 通知內容大小上限
+const maxCallbackBodySize = 1 << 20
+
+// decodeCallback 解析並驗證綠界主動通知，回傳解密後的資料
+// 通知格式與 API 回應相同：外層 JSON 含 MerchantID 與 AES 加密的 Data
+func (c *Client) decodeCallback(body []byte) ([]byte, error) {
+	var envelope BaseResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析通知失敗: %v", err))
+	}
+	
+	if merchantID := merchantIDString(envelope.MerchantID); merchantID != c.MerchantID {
+		return nil, NewError(ErrCodeValidation, fmt.Sprintf("MerchantID 不符: %s", merchantID))
+	}
+	
+	if envelope.Data == "" {
+		return nil, NewError(ErrCodeResponse, "通知缺少 Data")
+	}
+	
+	decryptedData, err := c.crypto.Decrypt(envelope.Data)
+	if err != nil {
+		return nil, NewError(ErrCodeCrypto, fmt.Sprintf("解密通知失敗: %v", err))
+	}
+	
+	return []byte(decryptedData), nil
+}
+
+// merchantIDString 轉換 MerchantID，綠界可能回傳 string 或 number
+func merchantIDString(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(id)
+	}
+}
+
+// readCallback 讀取通知內容
+func readCallback(r *http.Request) ([]byte, error) {
+	if r.Method != http.MethodPost {
+		return nil, NewError(ErrCodeRequest, fmt.Sprintf("不支援的 HTTP 方法: %s", r.Method))
+	}
+	
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+	if err != nil {
+		return nil, NewError(ErrCodeRequest, fmt.Sprintf("讀取通知失敗: %v", err))
+	}
+	
+	return body, nil
+}
+
+// callbackFailure 處理通知失敗時回覆綠界的固定訊息，錯誤內容只記錄於日誌
+const callbackFailure = "0|Error"
+
+// writeCallbackResult 回覆綠界通知結果，綠界收到 1|OK 後才會停止重送
+func (c *Client) writeCallbackResult(w http.ResponseWriter, r *http.Request, status int, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	if err != nil {
+		if c.logger != nil {
+			c.logger.LogAttrs(r.Context(), slog.LevelWarn, "ecpay: 處理綠界通知失敗",
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Any("error", err))
+		}
+		io.WriteString(w, callbackFailure)
+		return
+	}
+	io.WriteString(w, "1|OK")
+}
+
+// AllowanceConsentHandler 線上折讓消費者同意結果通知處理器
+type AllowanceConsentHandler struct {
+	client   *Client
+	callback func(ctx context.Context, result *AllowanceConsentResult) error
+}
+
+// NewAllowanceConsentHandler 建立線上折讓通知處理器
+// callback 回傳錯誤時會回覆失敗，讓綠界稍後重送
+func NewAllowanceConsentHandler(client *Client, callback func(ctx context.Context, result *AllowanceConsentResult) error) *AllowanceConsentHandler {
+	return &AllowanceConsentHandler{
+		client:   client,
+		callback: callback,
+	}
+}
+
+// ServeHTTP 實作 http.Handler
+func (h *AllowanceConsentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readCallback(r)
+	if err != nil {
+		h.client.writeCallbackResult(w, r, http.StatusBadRequest, err)
+		return
+	}
+	
+	result, err := h.client.ParseAllowanceConsent(body)
+	if err != nil {
+		h.client.writeCallbackResult(w, r, http.StatusBadRequest, err)
+		return
+	}
+	
+	if err := h.callback(r.Context(), result); err != nil {
+		h.client.writeCallbackResult(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	
+	h.client.writeCallbackResult(w, r, http.StatusOK, nil)
+}
+
+// ParseAllowanceConsent 解析並驗證線上折讓同意結果通知
+func (c *Client) ParseAllowanceConsent(body []byte) (*AllowanceConsentResult, error) {
+	decryptedData, err := c.decodeCallback(body)
+	if err != nil {
+		return nil, err
+	}
+	
+	var data allowanceConsentData
+	if err := json.Unmarshal(decryptedData, &data); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析線上折讓通知失敗: %v", err))
+	}
+	
+	result := &AllowanceConsentResult{
+		RtnCode:         data.RtnCode,
+		RtnMsg:          data.RtnMsg,
+		Agreed:          data.RtnCode == 1,
+		AllowanceNo:     data.IAAllowNo,
+		InvoiceNo:       data.IAInvoiceNo,
+		RemainingAmount: data.IARemainAllowanceAmount,
+	}
+	
+	if data.IADate != "" {
+		result.AllowanceDate, err = ParseInvoiceDate(data.IADate)
+		if err != nil {
+			return nil, NewError(ErrCodeParse, fmt.Sprintf("折讓日期格式錯誤: %s", data.IADate))
+		}
+	}
+	
+	return result, nil
+}
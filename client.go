@@ -2,11 +2,17 @@ package ecpay
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"os"
 	"time"
+	
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client 綠界電子發票客戶端
@@ -18,11 +24,40 @@ type Client struct {
 	httpClient *http.Client
 	debug      bool
 	crypto     *CryptoHandler
+	
+	// WithTransport / WithTimeout 的設定，所有選項執行後才套用至 httpClient，
+	// 避免與 WithHTTPClient 的先後順序影響結果
+	transport http.RoundTripper
+	timeout   *time.Duration
+	
+	// 開立發票前是否向綠界驗證手機條碼與捐贈碼
+	verifyCarrier bool
+	
+	// 延遲開立已使用的 Tsr
+	tsrStore TsrStore
+	
+	// 自動重試策略，nil 表示不重試
+	retry *RetryPolicy
+	
+	// 請求標頭
+	revision  string
+	userAgent string
+	
+	// 結構化日誌，nil 表示不記錄；redact 為記錄時遮蔽的欄位
+	// debugLogger 表示 logger 是 SetDebug 自動建立的，關閉除錯模式時一併移除；debugOutput 為其輸出位置
+	logger      *slog.Logger
+	redact      map[string]bool
+	debugLogger bool
+	debugOutput io.Writer
+	
+	// OpenTelemetry 追蹤，未啟用時為 no-op
+	tracer trace.Tracer
 }
 
-// NewClient 建立新的客戶端
-func NewClient(merchantID, hashKey, hashIV string, env Environment) *Client {
-	return &Client{
+// NewClient 建立新的客戶端，可透過 opts 調整 HTTP 設定
+// 設定不正確時（例如金鑰長度錯誤、環境網址無效）回傳 ErrCodeValidation 錯誤
+func NewClient(merchantID, hashKey, hashIV string, env Environment, opts ...Option) (*Client, error) {
+	c := &Client{
 		MerchantID: merchantID,
 		HashKey:    hashKey,
 		HashIV:     hashIV,
@@ -30,15 +65,53 @@ func NewClient(merchantID, hashKey, hashIV string, env Environment) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		debug:  false,
-		crypto: NewCryptoHandler(hashKey, hashIV),
+		debug:       false,
+		crypto:      NewCryptoHandler(hashKey, hashIV),
+		revision:    DefaultRevision,
+		tsrStore:    NewMemoryTsrStore(),
+		redact:      redactSet(DefaultRedactFields),
+		debugOutput: os.Stdout,
+		tracer:      noopTracer,
+	}
+	
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
 	}
+	
+	if c.transport != nil {
+		c.httpClient.Transport = c.transport
+	}
+	if c.timeout != nil {
+		c.httpClient.Timeout = *c.timeout
+	}
+	
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	
+	return c, nil
 }
 
-// SetDebug 設定除錯模式
+// SetDebug 設定除錯模式，日誌會額外包含遮蔽個資後的請求與回應內容
+// 未透過 WithLogger 設定日誌時，輸出至 WithDebugOutput 設定的位置（預設為標準輸出），關閉除錯模式後即不再輸出
 func (c *Client) SetDebug(debug bool) {
 	c.debug = debug
-	c.crypto.SetDebug(debug)
+	if debug && c.logger == nil {
+		c.logger = slog.New(slog.NewTextHandler(c.debugOutput, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		c.debugLogger = true
+	}
+	if !debug && c.debugLogger {
+		c.logger = nil
+		c.debugLogger = false
+	}
+	
+	if debug {
+		c.crypto.SetLogger(c.logger)
+	} else {
+		c.crypto.SetLogger(nil)
+	}
 }
 
 // SetTimeout 設定逾時時間
@@ -46,23 +119,94 @@ func (c *Client) SetTimeout(timeout time.Duration) {
 	c.httpClient.Timeout = timeout
 }
 
-// sendRequest 發送 API 請求
-func (c *Client) sendRequest(apiPath string, data interface{}) ([]byte, error) {
+// SetTransport 設定 HTTP 傳輸層，例如 ecpaytest 的錄製與重播 RoundTripper
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
+// SetRetryPolicy 設定自動重試策略，nil 表示不重試
+// 查詢類 API 遇到網路錯誤時直接重送；開立發票重送前會先以 RelateNumber 查詢，確認前次請求未開立
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retry = policy
+}
+
+// sendRequest 發送 API 請求，查詢類 API 依重試策略自動重試
+func (c *Client) sendRequest(ctx context.Context, apiPath string, data interface{}) (respData []byte, err error) {
+	ctx, span := c.startSpan(ctx, apiPath, data)
+	defer func() {
+		endSpan(span, respData, err)
+	}()
+	
+	if c.retry == nil || !readOnlyAPIs[apiPath] {
+		return c.send(ctx, apiPath, data)
+	}
+	
+	err = c.withRetry(ctx, func(attempt int) error {
+		var err error
+		respData, err = c.send(ctx, apiPath, data)
+		return err
+	})
+	return respData, err
+}
+
+// sendFunc 發送 API 請求的方式，sendRequest 或 sendOnce
+type sendFunc func(ctx context.Context, apiPath string, data interface{}) ([]byte, error)
+
+// sendOnce 發送一次 API 請求，不論重試策略都不重試
+func (c *Client) sendOnce(ctx context.Context, apiPath string, data interface{}) (respData []byte, err error) {
+	ctx, span := c.startSpan(ctx, apiPath, data)
+	defer func() {
+		endSpan(span, respData, err)
+	}()
+	
+	return c.send(ctx, apiPath, data)
+}
+
+// send 發送一次 API 請求
+func (c *Client) send(ctx context.Context, apiPath string, data interface{}) (respData []byte, err error) {
+	var transCode int
+	start := time.Now()
+	defer func() {
+		c.logRequest(ctx, apiPath, data, respData, transCode, time.Since(start), err)
+	}()
+	
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+	
+	req, err := c.newAPIRequest(ctx, apiPath, data)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 發送請求並讀取回應
+	body, err := c.roundTrip(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	
+	respData, transCode, err = c.decodeResponse(ctx, body)
+	return respData, err
+}
+
+// newAPIRequest 加密請求資料並建立 HTTP 請求
+func (c *Client) newAPIRequest(ctx context.Context, apiPath string, data interface{}) (*http.Request, error) {
 	// 將資料轉換為 JSON
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, NewError(ErrCodeRequest, fmt.Sprintf("JSON 編碼失敗: %v", err))
 	}
 	
-	if c.debug {
-		fmt.Printf("=== 原始請求資料 ===\n%s\n", string(jsonData))
-	}
-	
 	// AES 加密
+	_, encryptSpan := c.tracer.Start(ctx, "ecpay.encrypt")
 	encryptedData, err := c.crypto.Encrypt(string(jsonData))
 	if err != nil {
-		return nil, NewError(ErrCodeCrypto, fmt.Sprintf("加密失敗: %v", err))
+		err := NewError(ErrCodeCrypto, fmt.Sprintf("加密失敗: %v", err))
+		recordError(encryptSpan, err)
+		encryptSpan.End()
+		return nil, err
 	}
+	encryptSpan.End()
 	
 	// 建立請求物件
 	request := BaseRequest{
@@ -70,7 +214,7 @@ func (c *Client) sendRequest(apiPath string, data interface{}) ([]byte, error) {
 		Data:       encryptedData,
 	}
 	request.RqHeader.Timestamp = time.Now().Unix()
-	request.RqHeader.Revision = "3.0.0"
+	request.RqHeader.Revision = c.revision
 	
 	// 轉換為 JSON
 	requestBody, err := json.Marshal(request)
@@ -80,57 +224,73 @@ func (c *Client) sendRequest(apiPath string, data interface{}) ([]byte, error) {
 	
 	// 建立 HTTP 請求
 	fullURL := fmt.Sprintf("%s%s", c.Env, apiPath)
-	req, err := http.NewRequest("POST", fullURL, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, NewError(ErrCodeRequest, fmt.Sprintf("建立 HTTP 請求失敗: %v", err))
 	}
 	
 	req.Header.Set("Content-Type", "application/json")
-	
-	if c.debug {
-		fmt.Printf("=== API 請求 ===\n")
-		fmt.Printf("URL: %s\n", fullURL)
-		fmt.Printf("Request Body: %s\n", string(requestBody))
-	}
-	
-	// 發送請求
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, NewError(ErrCodeNetwork, fmt.Sprintf("發送請求失敗: %v", err))
-	}
-	defer resp.Body.Close()
-	
-	// 讀取回應
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, NewError(ErrCodeResponse, fmt.Sprintf("讀取回應失敗: %v", err))
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
 	}
-	
-	if c.debug {
-		fmt.Printf("=== API 回應 ===\n")
-		fmt.Printf("Response Body: %s\n", string(body))
-	}
-	
+	return req, nil
+}
+
+// decodeResponse 檢查回應封包的 TransCode 並解密資料，同時回傳 TransCode
+func (c *Client) decodeResponse(ctx context.Context, body []byte) ([]byte, int, error) {
 	// 解析基本回應
 	var baseResp BaseResponse
 	if err := json.Unmarshal(body, &baseResp); err != nil {
-		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析回應失敗: %v", err))
+		return nil, 0, NewError(ErrCodeParse, fmt.Sprintf("解析回應失敗: %v", err))
 	}
 	
 	// 檢查回應狀態
+	transCode := baseResp.TransCode
+	trace.SpanFromContext(ctx).SetAttributes(attrTransCode.Int(transCode))
 	if baseResp.TransCode != 1 {
-		return nil, NewError(ErrCodeAPI, fmt.Sprintf("%s (Code: %d)", baseResp.TransMsg, baseResp.TransCode))
+		return nil, transCode, NewError(ErrCodeAPI, fmt.Sprintf("%s (Code: %d)", baseResp.TransMsg, baseResp.TransCode))
 	}
 	
 	// 解密回應資料
+	_, decryptSpan := c.tracer.Start(ctx, "ecpay.decrypt")
 	decryptedData, err := c.crypto.Decrypt(baseResp.Data)
 	if err != nil {
-		return nil, NewError(ErrCodeCrypto, fmt.Sprintf("解密回應失敗: %v", err))
+		err := NewError(ErrCodeCrypto, fmt.Sprintf("解密回應失敗: %v", err))
+		recordError(decryptSpan, err)
+		decryptSpan.End()
+		return nil, transCode, err
+	}
+	decryptSpan.End()
+	
+	return []byte(decryptedData), transCode, nil
+}
+
+// roundTrip 發送 HTTP 請求並讀取回應本文
+func (c *Client) roundTrip(ctx context.Context, req *http.Request) (body []byte, err error) {
+	ctx, span := c.tracer.Start(ctx, "ecpay.http", trace.WithSpanKind(trace.SpanKindClient), httpSpanOptions(req))
+	defer func() {
+		recordError(span, err)
+		span.End()
+	}()
+	
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, contextError(ctxErr)
+		}
+		return nil, NewError(ErrCodeNetwork, fmt.Sprintf("發送請求失敗: %v", err))
 	}
+	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 	
-	if c.debug {
-		fmt.Printf("=== 解密後回應 ===\n%s\n", decryptedData)
+	// 讀取回應
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, contextError(ctxErr)
+		}
+		return nil, NewError(ErrCodeResponse, fmt.Sprintf("讀取回應失敗: %v", err))
 	}
 	
-	return []byte(decryptedData), nil
+	return body, nil
 }
\ No newline at end of file
@@ -29,6 +29,37 @@ const (
 	InvTypeGeneral = "07" // 一般稅額
 	InvTypeSpecial = "08" // 特種稅額
 	
+	// 字軌適用發票種類
+	InvoiceCategoryB2C = 1 // B2C
+	InvoiceCategoryB2B = 2 // B2B
+	
+	// 字軌使用狀態（查詢用）
+	WordUseStatusAll      = 0 // 全部
+	WordUseStatusUnused   = 1 // 未啟用
+	WordUseStatusInUse    = 2 // 使用中
+	WordUseStatusDisabled = 3 // 已停用
+	WordUseStatusPaused   = 4 // 暫停中
+	WordUseStatusPending  = 5 // 待審核
+	WordUseStatusRejected = 6 // 審核不通過
+	
+	// 字軌狀態設定
+	WordStatusDisable InvoiceWordStatus = 0 // 停用
+	WordStatusPause   InvoiceWordStatus = 1 // 暫停
+	WordStatusEnable  InvoiceWordStatus = 2 // 啟用
+	
+	// B2B 交易對象發票傳遞模式
+	B2BExchangeModeStorage  = "0" // 存證
+	B2BExchangeModeExchange = "1" // 交換
+	
+	// B2B 發票查詢類別
+	B2BInvoiceCategorySales    = 0 // 銷項發票
+	B2BInvoiceCategoryPurchase = 1 // 進項發票
+	
+	// B2B 交換模式發票狀態
+	B2BExchangeStatusPending   = "0" // 待確認
+	B2BExchangeStatusConfirmed = "1" // 已確認
+	B2BExchangeStatusRejected  = "2" // 已退回
+	
 	// VAT 設定
 	VatYes = "1" // 商品單價含稅
 	VatNo  = "0" // 商品單價未稅
@@ -37,7 +68,60 @@ const (
 	InvoiceStatusNormal  = "1" // 正常
 	InvoiceStatusInvalid = "0" // 作廢
 	
+	// 折讓通知類別
+	AllowanceNotifySMS   = "S" // 簡訊
+	AllowanceNotifyEmail = "E" // 電子郵件
+	AllowanceNotifyAll   = "A" // 皆通知
+	AllowanceNotifyNone  = "N" // 不通知
+	
+	// 折讓查詢類別
+	AllowanceSearchByAllowanceNo = "0" // 以折讓單號查詢
+	AllowanceSearchByInvoiceNo   = "1" // 以發票號碼查詢
+	
+	// 列印格式
+	PrintStyleSingle    = "1" // 一式一份
+	PrintStyleDuplicate = "2" // 一式兩份（含副本）
+	PrintStyleA4        = "3" // A4 格式
+	PrintStyleReceipt   = "4" // 熱感應紙（收據）格式
+	
+	// 列印時是否顯示商品明細
+	ShowingDetailYes = "1" // 顯示
+	ShowingDetailNo  = "2" // 不顯示
+	
+	// 延遲註記
+	DelayFlagDelay   = "1" // 延遲開立
+	DelayFlagTrigger = "2" // 觸發開立
+	
+	// 交易類別
+	PayTypeECPay = "2"     // 綠界金流
+	PayActECPay  = "ECPAY" // 交易類別名稱
+	
 	// 上傳狀態
 	UploadStatusYes = "1" // 已上傳
 	UploadStatusNo  = "0" // 未上傳
+)
+
+const (
+	// 通知發送類別
+	InvoiceTagIssue            InvoiceTag = "I"  // 發票開立
+	InvoiceTagInvalid          InvoiceTag = "II" // 發票作廢
+	InvoiceTagAllowance        InvoiceTag = "A"  // 折讓開立
+	InvoiceTagAllowanceInvalid InvoiceTag = "AI" // 折讓作廢
+	InvoiceTagWinning          InvoiceTag = "AW" // 發票中獎
+	
+	// 通知方式
+	NotifyChannelSMS   NotifyChannel = "S" // 簡訊
+	NotifyChannelEmail NotifyChannel = "E" // 電子郵件
+	NotifyChannelAll   NotifyChannel = "A" // 皆通知
+	
+	// 通知對象
+	NotifyTargetCustomer NotifyTarget = "C" // 客戶
+	NotifyTargetMerchant NotifyTarget = "M" // 特店
+	NotifyTargetAll      NotifyTarget = "A" // 皆發送
+)
+
+// 綠界業務回應代碼
+const (
+	rtnCodeNotFound   = 1600003 // 查無資料
+	rtnCodeDuplicated = 4000003 // RelateNumber 重複
 )
\ No newline at end of file
@@ -6,14 +6,15 @@ import (
 	"crypto/cipher"
 	"encoding/base64"
 	"fmt"
+	"log/slog"
 	"net/url"
 )
 
 // CryptoHandler AES 加解密處理器
 type CryptoHandler struct {
-	key   []byte
-	iv    []byte
-	debug bool
+	key    []byte
+	iv     []byte
+	logger *slog.Logger
 }
 
 // NewCryptoHandler 建立新的加解密處理器
@@ -24,9 +25,18 @@ func NewCryptoHandler(hashKey, hashIV string) *CryptoHandler {
 	}
 }
 
-// SetDebug 設定除錯模式
+// SetDebug 設定除錯模式，以 slog.Default() 記錄加解密的資料長度（不記錄內容）
 func (ch *CryptoHandler) SetDebug(debug bool) {
-	ch.debug = debug
+	if debug {
+		ch.logger = slog.Default()
+	} else {
+		ch.logger = nil
+	}
+}
+
+// SetLogger 設定記錄加解密資料長度的日誌，nil 表示不記錄
+func (ch *CryptoHandler) SetLogger(logger *slog.Logger) {
+	ch.logger = logger
 }
 
 // Encrypt AES-128-CBC 加密 (PKCS7 Padding)
@@ -52,11 +62,10 @@ func (ch *CryptoHandler) Encrypt(plainText string) (string, error) {
 	// Step 5: Base64 編碼
 	result := base64.StdEncoding.EncodeToString(cipherText)
 	
-	if ch.debug {
-		fmt.Printf("=== AES 加密 ===\n")
-		fmt.Printf("原始資料長度: %d\n", len(plainText))
-		fmt.Printf("URL Encode 後: %s\n", urlEncoded)
-		fmt.Printf("加密後 (Base64): %s\n", result)
+	if ch.logger != nil {
+		ch.logger.Debug("ecpay: AES 加密",
+			slog.Int("plain_length", len(plainText)),
+			slog.Int("encrypted_length", len(result)))
 	}
 	
 	return result, nil
@@ -76,6 +85,10 @@ func (ch *CryptoHandler) Decrypt(encryptedText string) (string, error) {
 		return "", fmt.Errorf("建立 AES cipher 失敗: %v", err)
 	}
 	
+	if len(cipherText) == 0 || len(cipherText)%block.BlockSize() != 0 {
+		return "", fmt.Errorf("密文長度錯誤: %d", len(cipherText))
+	}
+	
 	// Step 3: CBC 模式解密
 	plainText := make([]byte, len(cipherText))
 	mode := cipher.NewCBCDecrypter(block, ch.iv)
@@ -90,9 +103,10 @@ func (ch *CryptoHandler) Decrypt(encryptedText string) (string, error) {
 		return "", fmt.Errorf("URL Decode 失敗: %v", err)
 	}
 	
-	if ch.debug {
-		fmt.Printf("=== AES 解密 ===\n")
-		fmt.Printf("解密後資料長度: %d\n", len(result))
+	if ch.logger != nil {
+		ch.logger.Debug("ecpay: AES 解密",
+			slog.Int("encrypted_length", len(encryptedText)),
+			slog.Int("plain_length", len(result)))
 	}
 	
 	return result, nil
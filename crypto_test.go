@@ -0,0 +1,28 @@
+package ecpay_test
+
+import (
+	"encoding/base64"
+	"testing"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+func TestDecryptCipherLength(t *testing.T) {
+	crypto := ecpay.NewCryptoHandler("ejCk326UnaZWKisg", "q9jcZX8Ib9LM8wYk")
+	
+	encrypted, err := crypto.Encrypt(`{"RtnCode":1}`)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if plain, err := crypto.Decrypt(encrypted); err != nil || plain != `{"RtnCode":1}` {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	
+	// 長度不是區塊大小倍數的密文須回傳錯誤，不可 panic
+	cipherText, _ := base64.StdEncoding.DecodeString(encrypted)
+	for _, n := range []int{0, 1, 15, 17, len(cipherText) - 1} {
+		if _, err := crypto.Decrypt(base64.StdEncoding.EncodeToString(cipherText[:n])); err == nil {
+			t.Errorf("Decrypt of %d-byte cipher text succeeded, want error", n)
+		}
+	}
+}
\ No newline at end of file
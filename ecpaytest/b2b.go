@@ -0,0 +1,459 @@
+package ecpaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+// B2BCustomer 模擬伺服器保存的 B2B 交易對象
+type B2BCustomer struct {
+	Identifier   string
+	CompanyName  string
+	ExchangeMode string // ecpay.B2BExchangeModeStorage / ecpay.B2BExchangeModeExchange
+	EmailAddress string
+}
+
+// B2BInvoice 模擬伺服器保存的 B2B 發票
+type B2BInvoice struct {
+	InvoiceNumber      string
+	InvoiceDate        time.Time
+	RelateNumber       string
+	RandomNumber       string
+	SellerIdentifier   string
+	CustomerIdentifier string
+	Category           int // ecpay.B2BInvoiceCategorySales / ecpay.B2BInvoiceCategoryPurchase
+	InvType            string
+	TaxType            string
+	SalesAmount        int
+	TaxAmount          int
+	TotalAmount        int
+	Items              []ecpay.B2BItem
+	ExchangeMode       string
+	ExchangeStatus     string // ecpay.B2BExchangeStatus*
+	Invalid            bool
+	InvalidConfirmed   bool
+	Remark             string
+}
+
+// B2BAllowance 模擬伺服器保存的 B2B 折讓
+type B2BAllowance struct {
+	AllowanceNo        string
+	CustomerIdentifier string
+	TaxAmount          int
+	TotalAmount        int
+	Items              []ecpay.B2BAllowanceItem
+	ExchangeStatus     string // ecpay.B2BExchangeStatus*
+	Invalid            bool
+	InvalidConfirmed   bool
+}
+
+// B2BInvoice 取得 B2B 發票目前狀態
+func (s *Server) B2BInvoice(invoiceNumber string) (B2BInvoice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	inv, ok := s.b2bInvoices[invoiceNumber]
+	if !ok {
+		return B2BInvoice{}, false
+	}
+	return *inv, true
+}
+
+// B2BAllowance 取得 B2B 折讓目前狀態
+func (s *Server) B2BAllowance(allowanceNo string) (B2BAllowance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	a, ok := s.b2bAllowances[allowanceNo]
+	if !ok {
+		return B2BAllowance{}, false
+	}
+	return *a, true
+}
+
+// ReceiveB2BInvoice 模擬供應商以交換模式開給我方的進項發票，狀態為待確認
+func (s *Server) ReceiveB2BInvoice(inv B2BInvoice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	inv.Category = ecpay.B2BInvoiceCategoryPurchase
+	inv.ExchangeMode = ecpay.B2BExchangeModeExchange
+	inv.ExchangeStatus = ecpay.B2BExchangeStatusPending
+	if inv.InvoiceDate.IsZero() {
+		inv.InvoiceDate = s.now()
+	}
+	s.b2bInvoices[inv.InvoiceNumber] = &inv
+}
+
+// b2bRoutes B2B API 路由
+func b2bRoutes() map[string]routeFunc {
+	return map[string]routeFunc{
+		"/B2BInvoice/MaintainMerchantCustomerData": (*Server).b2bMaintainCustomer,
+		"/B2BInvoice/Issue":                        (*Server).b2bIssue,
+		"/B2BInvoice/Invalid":                      (*Server).b2bInvalid,
+		"/B2BInvoice/Allowance":                    (*Server).b2bAllowance,
+		"/B2BInvoice/GetIssue":                     (*Server).b2bGetIssue,
+		"/B2BInvoice/GetIssueList":                 (*Server).b2bGetIssueList,
+		"/B2BInvoice/IssueConfirm":                 (*Server).b2bIssueConfirm,
+		"/B2BInvoice/Reject":                       (*Server).b2bReject,
+		"/B2BInvoice/RejectConfirm":                (*Server).b2bRejectConfirm,
+		"/B2BInvoice/InvalidConfirm":               (*Server).b2bInvalidConfirm,
+		"/B2BInvoice/AllowanceConfirm":             (*Server).b2bAllowanceConfirm,
+		"/B2BInvoice/AllowanceInvalidConfirm":      (*Server).b2bAllowanceInvalidConfirm,
+	}
+}
+
+// b2bMaintainCustomer 新增、修改或刪除交易對象
+func (s *Server) b2bMaintainCustomer(data []byte) interface{} {
+	var req ecpay.B2BMaintainCustomerRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	_, exists := s.customers[req.Identifier]
+	switch req.Action {
+	case "Add":
+		if exists {
+			return result(RtnCodeDuplicated, "交易對象已存在")
+		}
+	case "Update", "Delete":
+		if !exists {
+			return result(RtnCodeNotFound, "查無交易對象")
+		}
+	default:
+		return result(RtnCodeFailed, "Action 錯誤")
+	}
+	
+	if req.Action == "Delete" {
+		delete(s.customers, req.Identifier)
+	} else {
+		s.customers[req.Identifier] = &B2BCustomer{
+			Identifier:   req.Identifier,
+			CompanyName:  req.CompanyName,
+			ExchangeMode: req.ExchangeMode,
+			EmailAddress: req.EmailAddress,
+		}
+	}
+	
+	return result(RtnCodeSuccess, "維護成功")
+}
+
+// b2bIssue 開立 B2B 發票，交易對象為交換模式時發票待對方確認
+func (s *Server) b2bIssue(data []byte) interface{} {
+	var req ecpay.B2BIssueRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	for _, inv := range s.b2bInvoices {
+		if inv.Category == ecpay.B2BInvoiceCategorySales && inv.RelateNumber == req.RelateNumber {
+			return result(RtnCodeDuplicated, "特店自訂編號重複")
+		}
+	}
+	
+	inv := &B2BInvoice{
+		InvoiceNumber:      fmt.Sprintf("%s%08d", s.header, s.nextNumber),
+		InvoiceDate:        s.now(),
+		RelateNumber:       req.RelateNumber,
+		RandomNumber:       fmt.Sprintf("%04d", (s.nextNumber*7919+1234)%10000),
+		SellerIdentifier:   s.MerchantID,
+		CustomerIdentifier: req.CustomerIdentifier,
+		Category:           ecpay.B2BInvoiceCategorySales,
+		InvType:            req.InvType,
+		TaxType:            req.TaxType,
+		SalesAmount:        req.SalesAmount,
+		TaxAmount:          req.TaxAmount,
+		TotalAmount:        req.TotalAmount,
+		Items:              req.Items,
+		ExchangeMode:       ecpay.B2BExchangeModeStorage,
+		Remark:             req.InvoiceRemark,
+	}
+	if c, ok := s.customers[req.CustomerIdentifier]; ok && c.ExchangeMode == ecpay.B2BExchangeModeExchange {
+		inv.ExchangeMode = ecpay.B2BExchangeModeExchange
+		inv.ExchangeStatus = ecpay.B2BExchangeStatusPending
+	}
+	s.nextNumber++
+	s.b2bInvoices[inv.InvoiceNumber] = inv
+	
+	resp := result(RtnCodeSuccess, "開立發票成功")
+	resp["InvoiceNumber"] = inv.InvoiceNumber
+	resp["InvoiceDate"] = inv.InvoiceDate.Format(dateTimeLayout)
+	resp["RandomNumber"] = inv.RandomNumber
+	return resp
+}
+
+// b2bInvalid 作廢 B2B 發票
+func (s *Server) b2bInvalid(data []byte) interface{} {
+	var req ecpay.B2BInvalidRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	inv, ok := s.b2bInvoices[req.InvoiceNumber]
+	if !ok || inv.Category != ecpay.B2BInvoiceCategorySales {
+		return result(RtnCodeNotFound, "查無發票資料")
+	}
+	
+	if inv.Invalid {
+		return result(RtnCodeFailed, "發票已作廢")
+	}
+	
+	inv.Invalid = true
+	inv.InvalidConfirmed = false
+	
+	resp := result(RtnCodeSuccess, "作廢發票成功")
+	resp["InvoiceNumber"] = inv.InvoiceNumber
+	return resp
+}
+
+// b2bAllowance 開立 B2B 折讓，交易對象為交換模式時折讓待對方確認
+func (s *Server) b2bAllowance(data []byte) interface{} {
+	var req ecpay.B2BAllowanceRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	for _, item := range req.Items {
+		inv, ok := s.b2bInvoices[item.OriginalInvoiceNumber]
+		if !ok || inv.Category != ecpay.B2BInvoiceCategorySales {
+			return result(RtnCodeNotFound, "查無發票資料")
+		}
+		if inv.Invalid {
+			return result(RtnCodeFailed, "發票已作廢")
+		}
+	}
+	
+	now := s.now()
+	a := &B2BAllowance{
+		AllowanceNo:        fmt.Sprintf("%s%08d", now.Format("20060102"), s.nextAllow),
+		CustomerIdentifier: req.CustomerIdentifier,
+		TaxAmount:          req.TaxAmount,
+		TotalAmount:        req.TotalAmount,
+		Items:              req.Items,
+	}
+	if c, ok := s.customers[req.CustomerIdentifier]; ok && c.ExchangeMode == ecpay.B2BExchangeModeExchange {
+		a.ExchangeStatus = ecpay.B2BExchangeStatusPending
+	}
+	s.nextAllow++
+	s.b2bAllowances[a.AllowanceNo] = a
+	
+	resp := result(RtnCodeSuccess, "開立折讓成功")
+	resp["AllowanceNo"] = a.AllowanceNo
+	return resp
+}
+
+// b2bGetIssue 查詢 B2B 發票
+func (s *Server) b2bGetIssue(data []byte) interface{} {
+	var req ecpay.B2BGetIssueRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	inv, ok := s.b2bInvoices[req.InvoiceNumber]
+	if !ok || inv.Category != req.InvoiceCategory {
+		return result(RtnCodeNotFound, "查無發票資料")
+	}
+	
+	resp := b2bInvoiceFields(inv)
+	resp["RtnCode"] = RtnCodeSuccess
+	resp["RtnMsg"] = "查詢成功"
+	return resp
+}
+
+// b2bInvoiceFields B2B 發票查詢回應欄位
+func b2bInvoiceFields(inv *B2BInvoice) map[string]interface{} {
+	invalidStatus := "0"
+	if inv.Invalid {
+		invalidStatus = "1"
+	}
+	
+	return map[string]interface{}{
+		"InvoiceNumber":      inv.InvoiceNumber,
+		"InvoiceDate":        inv.InvoiceDate.Format(dateTimeLayout),
+		"RelateNumber":       inv.RelateNumber,
+		"RandomNumber":       inv.RandomNumber,
+		"SellerIdentifier":   inv.SellerIdentifier,
+		"CustomerIdentifier": inv.CustomerIdentifier,
+		"InvType":            inv.InvType,
+		"TaxType":            inv.TaxType,
+		"SalesAmount":        inv.SalesAmount,
+		"TaxAmount":          inv.TaxAmount,
+		"TotalAmount":        inv.TotalAmount,
+		"Invalid_Status":     invalidStatus,
+		"Upload_Status":      ecpay.UploadStatusNo,
+		"ExchangeMode":       inv.ExchangeMode,
+		"ExchangeStatus":     inv.ExchangeStatus,
+		"InvoiceRemark":      inv.Remark,
+		"Items":              inv.Items,
+	}
+}
+
+// b2bGetIssueList 查詢 B2B 發票清單
+func (s *Server) b2bGetIssueList(data []byte) interface{} {
+	var req struct {
+		InvoiceCategory int    `json:"InvoiceCategory"`
+		BeginDate       string `json:"BeginDate"`
+		EndDate         string `json:"EndDate"`
+		ExchangeMode    string `json:"ExchangeMode"`
+		ExchangeStatus  string `json:"ExchangeStatus"`
+		NumPerPage      int    `json:"NumPerPage"`
+		ShowingPage     int    `json:"ShowingPage"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	begin, err1 := time.Parse("2006-01-02", req.BeginDate)
+	end, err2 := time.Parse("2006-01-02", req.EndDate)
+	if err1 != nil || err2 != nil || req.NumPerPage <= 0 || req.ShowingPage <= 0 {
+		return result(RtnCodeFailed, "查詢條件錯誤")
+	}
+	end = end.AddDate(0, 0, 1)
+	
+	var matched []*B2BInvoice
+	for _, inv := range s.b2bInvoices {
+		date := time.Date(inv.InvoiceDate.Year(), inv.InvoiceDate.Month(), inv.InvoiceDate.Day(), 0, 0, 0, 0, time.UTC)
+		if inv.Category != req.InvoiceCategory || date.Before(begin) || !date.Before(end) {
+			continue
+		}
+		if (req.ExchangeMode != "" && inv.ExchangeMode != req.ExchangeMode) ||
+			(req.ExchangeStatus != "" && inv.ExchangeStatus != req.ExchangeStatus) {
+			continue
+		}
+		matched = append(matched, inv)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].InvoiceNumber < matched[j].InvoiceNumber
+	})
+	
+	list := []map[string]interface{}{}
+	for i := (req.ShowingPage - 1) * req.NumPerPage; i < len(matched) && len(list) < req.NumPerPage; i++ {
+		list = append(list, b2bInvoiceFields(matched[i]))
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["TotalCount"] = len(matched)
+	resp["InvoiceData"] = list
+	return resp
+}
+
+// exchangeRequest 交換模式確認與退回的請求欄位
+type exchangeRequest struct {
+	InvoiceNumber string `json:"InvoiceNumber"`
+	AllowanceNo   string `json:"AllowanceNo"`
+}
+
+// exchangeInvoice 取得交換模式的發票，找不到時回傳錯誤回應
+func (s *Server) exchangeInvoice(data []byte) (*B2BInvoice, map[string]interface{}) {
+	var req exchangeRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	inv, ok := s.b2bInvoices[req.InvoiceNumber]
+	if !ok || inv.ExchangeMode != ecpay.B2BExchangeModeExchange {
+		return nil, result(RtnCodeNotFound, "查無交換模式發票")
+	}
+	return inv, nil
+}
+
+// exchangeAllowance 取得交換模式的折讓，找不到時回傳錯誤回應
+func (s *Server) exchangeAllowance(data []byte) (*B2BAllowance, map[string]interface{}) {
+	var req exchangeRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	a, ok := s.b2bAllowances[req.AllowanceNo]
+	if !ok || a.ExchangeStatus == "" {
+		return nil, result(RtnCodeNotFound, "查無交換模式折讓")
+	}
+	return a, nil
+}
+
+// b2bIssueConfirm 確認待確認的發票
+func (s *Server) b2bIssueConfirm(data []byte) interface{} {
+	inv, failure := s.exchangeInvoice(data)
+	if failure != nil {
+		return failure
+	}
+	if inv.ExchangeStatus != ecpay.B2BExchangeStatusPending {
+		return result(RtnCodeFailed, "發票非待確認狀態")
+	}
+	
+	inv.ExchangeStatus = ecpay.B2BExchangeStatusConfirmed
+	return result(RtnCodeSuccess, "確認成功")
+}
+
+// b2bReject 退回待確認的發票
+func (s *Server) b2bReject(data []byte) interface{} {
+	inv, failure := s.exchangeInvoice(data)
+	if failure != nil {
+		return failure
+	}
+	if inv.ExchangeStatus != ecpay.B2BExchangeStatusPending {
+		return result(RtnCodeFailed, "發票非待確認狀態")
+	}
+	
+	inv.ExchangeStatus = ecpay.B2BExchangeStatusRejected
+	return result(RtnCodeSuccess, "退回成功")
+}
+
+// b2bRejectConfirm 開立方確認發票已被退回
+func (s *Server) b2bRejectConfirm(data []byte) interface{} {
+	inv, failure := s.exchangeInvoice(data)
+	if failure != nil {
+		return failure
+	}
+	if inv.ExchangeStatus != ecpay.B2BExchangeStatusRejected {
+		return result(RtnCodeFailed, "發票未被退回")
+	}
+	
+	return result(RtnCodeSuccess, "確認成功")
+}
+
+// b2bInvalidConfirm 確認對方作廢的發票
+func (s *Server) b2bInvalidConfirm(data []byte) interface{} {
+	inv, failure := s.exchangeInvoice(data)
+	if failure != nil {
+		return failure
+	}
+	if !inv.Invalid || inv.InvalidConfirmed {
+		return result(RtnCodeFailed, "發票非待確認作廢狀態")
+	}
+	
+	inv.InvalidConfirmed = true
+	return result(RtnCodeSuccess, "確認成功")
+}
+
+// b2bAllowanceConfirm 確認待確認的折讓
+func (s *Server) b2bAllowanceConfirm(data []byte) interface{} {
+	a, failure := s.exchangeAllowance(data)
+	if failure != nil {
+		return failure
+	}
+	if a.ExchangeStatus != ecpay.B2BExchangeStatusPending {
+		return result(RtnCodeFailed, "折讓非待確認狀態")
+	}
+	
+	a.ExchangeStatus = ecpay.B2BExchangeStatusConfirmed
+	return result(RtnCodeSuccess, "確認成功")
+}
+
+// b2bAllowanceInvalidConfirm 確認對方作廢的折讓
+func (s *Server) b2bAllowanceInvalidConfirm(data []byte) interface{} {
+	a, failure := s.exchangeAllowance(data)
+	if failure != nil {
+		return failure
+	}
+	if !a.Invalid || a.InvalidConfirmed {
+		return result(RtnCodeFailed, "折讓非待確認作廢狀態")
+	}
+	
+	a.InvalidConfirmed = true
+	return result(RtnCodeSuccess, "確認成功")
+}
\ No newline at end of file
@@ -0,0 +1,729 @@
+package ecpaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+// 模擬伺服器回傳的業務錯誤代碼
+const (
+	RtnCodeSuccess    = 1
+	RtnCodeFailed     = 0
+	RtnCodeNotFound   = 1600003 // 查無資料
+	RtnCodeDuplicated = 4000003 // RelateNumber 重複
+)
+
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// Invoice 模擬伺服器保存的發票
+type Invoice struct {
+	InvoiceNo          string
+	InvoiceDate        time.Time
+	RandomNumber       string
+	RelateNumber       string
+	CustomerIdentifier string
+	CustomerName       string
+	CustomerEmail      string
+	CarrierType        string
+	CarrierNum         string
+	LoveCode           string
+	TaxType            string
+	InvType            string
+	SalesAmount        int
+	RemainingAllowance int
+	Items              []ecpay.Item
+	Invalid            bool
+	InvalidDate        time.Time
+	InvalidReason      string
+}
+
+// Allowance 模擬伺服器保存的折讓
+type Allowance struct {
+	AllowanceNo   string
+	InvoiceNo     string
+	AllowanceDate time.Time
+	Amount        int
+	CustomerName  string
+	Items         []ecpay.Item
+	Collegiate    bool // 線上折讓
+	Invalid       bool
+	InvalidDate   time.Time
+	InvalidReason string
+}
+
+// delayedInvoice 延遲開立中的發票
+type delayedInvoice struct {
+	request   ecpay.IssueInvoiceRequest
+	invoiceNo string // 已觸發開立的發票號碼
+	canceled  bool
+}
+
+// Invoice 取得發票目前狀態
+func (s *Server) Invoice(invoiceNo string) (Invoice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return Invoice{}, false
+	}
+	return *inv, true
+}
+
+// InvoiceByRelateNumber 以 RelateNumber 取得發票目前狀態
+func (s *Server) InvoiceByRelateNumber(relateNumber string) (Invoice, bool) {
+	s.mu.Lock()
+	no, ok := s.relates[relateNumber]
+	s.mu.Unlock()
+	if !ok {
+		return Invoice{}, false
+	}
+	return s.Invoice(no)
+}
+
+// Allowance 取得折讓目前狀態
+func (s *Server) Allowance(allowanceNo string) (Allowance, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	a, ok := s.allowances[allowanceNo]
+	if !ok {
+		return Allowance{}, false
+	}
+	return *a, true
+}
+
+// AddCompany 設定統一編號查詢公司名稱的結果，未設定的統一編號查無資料
+func (s *Server) AddCompany(taxID, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.companies[taxID] = name
+}
+
+// b2cRoutes B2C API 路由
+func b2cRoutes() map[string]routeFunc {
+	return map[string]routeFunc{
+		"/B2CInvoice/Issue":                        (*Server).issue,
+		"/B2CInvoice/OfflineIssue":                 (*Server).offlineIssue,
+		"/B2CInvoice/DelayIssue":                   (*Server).delayIssue,
+		"/B2CInvoice/TriggerIssue":                 (*Server).triggerIssue,
+		"/B2CInvoice/CancelDelayIssue":             (*Server).cancelDelayIssue,
+		"/B2CInvoice/Invalid":                      (*Server).invalid,
+		"/B2CInvoice/VoidWithReIssue":              (*Server).voidWithReIssue,
+		"/B2CInvoice/GetIssue":                     (*Server).getIssue,
+		"/B2CInvoice/GetIssueList":                 (*Server).getIssueList,
+		"/B2CInvoice/GetInvalid":                   (*Server).getInvalid,
+		"/B2CInvoice/Allowance":                    (*Server).allowance,
+		"/B2CInvoice/AllowanceByCollegiate":        (*Server).allowanceByCollegiate,
+		"/B2CInvoice/AllowanceInvalid":             (*Server).allowanceInvalid,
+		"/B2CInvoice/AllowanceInvalidByCollegiate": (*Server).allowanceInvalidByCollegiate,
+		"/B2CInvoice/GetAllowanceList":             (*Server).getAllowanceList,
+		"/B2CInvoice/GetAllowanceInvalid":          (*Server).getAllowanceInvalid,
+		"/B2CInvoice/CheckBarcode":                 (*Server).checkCode,
+		"/B2CInvoice/CheckLoveCode":                (*Server).checkCode,
+		"/B2CInvoice/InvoiceNotify":                (*Server).ok,
+		"/B2CInvoice/InvoicePrintPDF":              (*Server).invoicePrintPDF,
+		"/B2CInvoice/GetCompanyNameByTaxID":        (*Server).getCompanyNameByTaxID,
+	}
+}
+
+// ok 僅回傳成功的 API
+func (s *Server) ok(data []byte) interface{} {
+	return result(RtnCodeSuccess, "成功")
+}
+
+// checkCode 手機條碼與捐贈碼一律視為存在
+func (s *Server) checkCode(data []byte) interface{} {
+	resp := result(RtnCodeSuccess, "成功")
+	resp["IsExist"] = "Y"
+	return resp
+}
+
+// issue 開立發票
+func (s *Server) issue(data []byte) interface{} {
+	var req ecpay.IssueInvoiceRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	if _, ok := s.relates[req.RelateNumber]; ok {
+		return result(RtnCodeDuplicated, "特店自訂編號重複")
+	}
+	
+	inv, failure := s.create(&req)
+	if failure != nil {
+		return failure
+	}
+	
+	resp := result(RtnCodeSuccess, "開立發票成功")
+	resp["InvoiceNo"] = inv.InvoiceNo
+	resp["InvoiceDate"] = inv.InvoiceDate.Format(dateTimeLayout)
+	resp["RandomNumber"] = inv.RandomNumber
+	return resp
+}
+
+// create 配號並保存發票，請求有誤時回傳錯誤回應
+func (s *Server) create(req *ecpay.IssueInvoiceRequest) (*Invoice, map[string]interface{}) {
+	if req.RelateNumber == "" {
+		return nil, result(RtnCodeFailed, "RelateNumber 不能為空")
+	}
+	
+	salesAmount, err := strconv.Atoi(req.SalesAmount)
+	if err != nil {
+		return nil, result(RtnCodeFailed, "SalesAmount 格式錯誤")
+	}
+	
+	inv := &Invoice{
+		InvoiceNo:          fmt.Sprintf("%s%08d", s.header, s.nextNumber),
+		InvoiceDate:        s.now(),
+		RandomNumber:       fmt.Sprintf("%04d", (s.nextNumber*7919+1234)%10000),
+		RelateNumber:       req.RelateNumber,
+		CustomerIdentifier: req.CustomerIdentifier,
+		CustomerName:       req.CustomerName,
+		CustomerEmail:      req.CustomerEmail,
+		CarrierType:        req.CarrierType,
+		CarrierNum:         req.CarrierNum,
+		LoveCode:           req.LoveCode,
+		TaxType:            req.TaxType,
+		InvType:            req.InvType,
+		SalesAmount:        salesAmount,
+		RemainingAllowance: salesAmount,
+		Items:              req.Items,
+	}
+	s.nextNumber++
+	s.invoices[inv.InvoiceNo] = inv
+	s.relates[inv.RelateNumber] = inv.InvoiceNo
+	return inv, nil
+}
+
+// delayIssue 延遲開立發票，發票在 TriggerIssue 時才開立
+// 模擬伺服器不計算延遲天數，DelayFlag 為 1 的發票同樣需以 TriggerIssue 觸發
+func (s *Server) delayIssue(data []byte) interface{} {
+	var req ecpay.DelayIssueRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	if _, ok := s.delayed[req.Tsr]; ok {
+		return result(RtnCodeDuplicated, "交易單號重複")
+	}
+	
+	if _, ok := s.relates[req.RelateNumber]; ok {
+		return result(RtnCodeDuplicated, "特店自訂編號重複")
+	}
+	
+	s.delayed[req.Tsr] = &delayedInvoice{request: req.IssueInvoiceRequest}
+	
+	resp := result(RtnCodeSuccess, "延遲開立成功")
+	resp["OrderNumber"] = req.Tsr
+	return resp
+}
+
+// triggerIssue 觸發開立延遲開立的發票
+func (s *Server) triggerIssue(data []byte) interface{} {
+	var req ecpay.TriggerIssueRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	d, ok := s.delayed[req.Tsr]
+	if !ok || d.canceled {
+		return result(RtnCodeNotFound, "查無延遲開立資料")
+	}
+	
+	if d.invoiceNo != "" {
+		return result(RtnCodeFailed, "發票已開立")
+	}
+	
+	if _, ok := s.relates[d.request.RelateNumber]; ok {
+		return result(RtnCodeDuplicated, "特店自訂編號重複")
+	}
+	
+	inv, failure := s.create(&d.request)
+	if failure != nil {
+		return failure
+	}
+	d.invoiceNo = inv.InvoiceNo
+	
+	return result(RtnCodeSuccess, "觸發開立成功")
+}
+
+// cancelDelayIssue 取消尚未開立的延遲開立發票
+func (s *Server) cancelDelayIssue(data []byte) interface{} {
+	var req ecpay.CancelDelayIssueRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	d, ok := s.delayed[req.Tsr]
+	if !ok || d.canceled {
+		return result(RtnCodeNotFound, "查無延遲開立資料")
+	}
+	
+	if d.invoiceNo != "" {
+		return result(RtnCodeFailed, "發票已開立，無法取消")
+	}
+	
+	d.canceled = true
+	return result(RtnCodeSuccess, "取消成功")
+}
+
+// offlineIssue 上傳離線開立發票，發票號碼須落在已設定的字軌內且未被使用
+func (s *Server) offlineIssue(data []byte) interface{} {
+	var req ecpay.OfflineIssueRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	if _, ok := s.relates[req.RelateNumber]; ok {
+		return result(RtnCodeDuplicated, "特店自訂編號重複")
+	}
+	
+	if _, ok := s.trackOf(req.InvoiceNo); !ok {
+		return result(RtnCodeFailed, "發票號碼不在字軌範圍內")
+	}
+	
+	if _, ok := s.invoices[req.InvoiceNo]; ok {
+		return result(RtnCodeFailed, "發票號碼已使用")
+	}
+	
+	invoiceDate, err := time.ParseInLocation(dateTimeLayout, req.InvoiceDate, time.Local)
+	if err != nil {
+		return result(RtnCodeFailed, "InvoiceDate 格式錯誤")
+	}
+	
+	salesAmount, err := strconv.Atoi(req.SalesAmount)
+	if err != nil {
+		return result(RtnCodeFailed, "SalesAmount 格式錯誤")
+	}
+	
+	inv := &Invoice{
+		InvoiceNo:          req.InvoiceNo,
+		InvoiceDate:        invoiceDate,
+		RandomNumber:       req.RandomNumber,
+		RelateNumber:       req.RelateNumber,
+		CustomerIdentifier: req.CustomerIdentifier,
+		CustomerName:       req.CustomerName,
+		CustomerEmail:      req.CustomerEmail,
+		CarrierType:        req.CarrierType,
+		CarrierNum:         req.CarrierNum,
+		LoveCode:           req.LoveCode,
+		TaxType:            req.TaxType,
+		InvType:            req.InvType,
+		SalesAmount:        salesAmount,
+		RemainingAllowance: salesAmount,
+		Items:              req.Items,
+	}
+	s.invoices[inv.InvoiceNo] = inv
+	s.relates[inv.RelateNumber] = inv.InvoiceNo
+	
+	resp := result(RtnCodeSuccess, "上傳成功")
+	resp["InvoiceNo"] = inv.InvoiceNo
+	return resp
+}
+
+// invalid 作廢發票
+func (s *Server) invalid(data []byte) interface{} {
+	var req ecpay.InvalidInvoiceRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	inv, failure := s.voidable(req.InvoiceNo)
+	if failure != nil {
+		return failure
+	}
+	
+	inv.Invalid = true
+	inv.InvalidDate = s.now()
+	inv.InvalidReason = req.Reason
+	
+	resp := result(RtnCodeSuccess, "作廢發票成功")
+	resp["InvoiceNo"] = inv.InvoiceNo
+	return resp
+}
+
+// voidable 取得可作廢的發票，不可作廢時回傳錯誤回應
+func (s *Server) voidable(invoiceNo string) (*Invoice, map[string]interface{}) {
+	inv, ok := s.invoices[invoiceNo]
+	if !ok {
+		return nil, result(RtnCodeNotFound, "查無發票資料")
+	}
+	
+	if inv.Invalid {
+		return nil, result(RtnCodeFailed, "發票已作廢")
+	}
+	
+	for _, a := range s.allowances {
+		if a.InvoiceNo == inv.InvoiceNo && !a.Invalid {
+			return nil, result(RtnCodeFailed, "發票已有折讓，請先作廢折讓")
+		}
+	}
+	
+	return inv, nil
+}
+
+// voidWithReIssue 作廢發票並以同一筆或新的 RelateNumber 重新開立
+func (s *Server) voidWithReIssue(data []byte) interface{} {
+	var req struct {
+		VoidModel struct {
+			InvoiceNo  string `json:"InvoiceNo"`
+			VoidReason string `json:"VoidReason"`
+		} `json:"VoidModel"`
+		IssueModel ecpay.IssueInvoiceRequest `json:"IssueModel"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	old, failure := s.voidable(req.VoidModel.InvoiceNo)
+	if failure != nil {
+		return failure
+	}
+	
+	if no, ok := s.relates[req.IssueModel.RelateNumber]; ok && no != old.InvoiceNo {
+		return result(RtnCodeDuplicated, "特店自訂編號重複")
+	}
+	
+	inv, failure := s.create(&req.IssueModel)
+	if failure != nil {
+		return failure
+	}
+	old.Invalid = true
+	old.InvalidDate = s.now()
+	old.InvalidReason = req.VoidModel.VoidReason
+	
+	resp := result(RtnCodeSuccess, "作廢重開成功")
+	resp["InvoiceNo"] = inv.InvoiceNo
+	resp["InvoiceDate"] = inv.InvoiceDate.Format(dateTimeLayout)
+	resp["RandomNumber"] = inv.RandomNumber
+	return resp
+}
+
+// lookup 依 RelateNumber 或 InvoiceNo 查詢發票
+func (s *Server) lookup(data []byte) (*Invoice, bool) {
+	var req struct {
+		RelateNumber string `json:"RelateNumber"`
+		InvoiceNo    string `json:"InvoiceNo"`
+	}
+	json.Unmarshal(data, &req)
+	
+	if req.InvoiceNo == "" {
+		req.InvoiceNo = s.relates[req.RelateNumber]
+	}
+	inv, ok := s.invoices[req.InvoiceNo]
+	return inv, ok
+}
+
+// getIssue 查詢發票
+func (s *Server) getIssue(data []byte) interface{} {
+	inv, ok := s.lookup(data)
+	if !ok {
+		return result(RtnCodeNotFound, "查無發票資料")
+	}
+	
+	resp := invoiceFields(inv)
+	resp["RtnCode"] = RtnCodeSuccess
+	resp["RtnMsg"] = "查詢成功"
+	return resp
+}
+
+// getCompanyNameByTaxID 以統一編號查詢公司名稱
+func (s *Server) getCompanyNameByTaxID(data []byte) interface{} {
+	var req struct {
+		UnifiedBusinessNo string `json:"UnifiedBusinessNo"`
+	}
+	json.Unmarshal(data, &req)
+	
+	name, ok := s.companies[req.UnifiedBusinessNo]
+	if !ok {
+		return result(RtnCodeNotFound, "查無資料")
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["CompanyName"] = name
+	return resp
+}
+
+// invoicePrintPDF 回傳發票證明聯 PDF，內容只含發票號碼與金額，不是可列印的證明聯
+func (s *Server) invoicePrintPDF(data []byte) interface{} {
+	inv, ok := s.lookup(data)
+	if !ok {
+		return result(RtnCodeNotFound, "查無發票資料")
+	}
+	
+	return pdfFile(fmt.Sprintf("%%PDF-1.4\n%% ecpaytest %s %d\n%%%%EOF\n", inv.InvoiceNo, inv.SalesAmount))
+}
+
+// invoiceFields 發票查詢回應欄位
+func invoiceFields(inv *Invoice) map[string]interface{} {
+	invalidStatus := "0"
+	if inv.Invalid {
+		invalidStatus = "1"
+	}
+	
+	return map[string]interface{}{
+		"IIS_Number":               inv.InvoiceNo,
+		"IIS_Relate_Number":        inv.RelateNumber,
+		"IIS_Identifier":           inv.CustomerIdentifier,
+		"IIS_Customer_Name":        inv.CustomerName,
+		"IIS_Customer_Email":       inv.CustomerEmail,
+		"IIS_Category":             "B2C",
+		"IIS_Type":                 inv.InvType,
+		"IIS_Tax_Type":             inv.TaxType,
+		"IIS_Sales_Amount":         inv.SalesAmount,
+		"IIS_Carrier_Type":         inv.CarrierType,
+		"IIS_Carrier_Num":          inv.CarrierNum,
+		"IIS_Love_Code":            inv.LoveCode,
+		"IIS_Create_Date":          inv.InvoiceDate.Format(dateTimeLayout),
+		"IIS_Issue_Status":         "1",
+		"IIS_Invalid_Status":       invalidStatus,
+		"IIS_Upload_Status":        ecpay.UploadStatusNo,
+		"IIS_Upload_Date":          "",
+		"IIS_Remain_Allowance_Amt": inv.RemainingAllowance,
+		"IIS_Random_Number":        inv.RandomNumber,
+		"Items":                    inv.Items,
+	}
+}
+
+// getIssueList 查詢發票清單
+func (s *Server) getIssueList(data []byte) interface{} {
+	var req struct {
+		BeginDate    string `json:"BeginDate"`
+		EndDate      string `json:"EndDate"`
+		NumPerPage   int    `json:"NumPerPage"`
+		ShowingPage  int    `json:"ShowingPage"`
+		QueryInvalid string `json:"Query_Invalid"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	begin, err1 := time.Parse("2006-01-02", req.BeginDate)
+	end, err2 := time.Parse("2006-01-02", req.EndDate)
+	if err1 != nil || err2 != nil || req.NumPerPage <= 0 || req.ShowingPage <= 0 {
+		return result(RtnCodeFailed, "查詢條件錯誤")
+	}
+	end = end.AddDate(0, 0, 1)
+	
+	var matched []*Invoice
+	for _, inv := range s.invoices {
+		date := time.Date(inv.InvoiceDate.Year(), inv.InvoiceDate.Month(), inv.InvoiceDate.Day(), 0, 0, 0, 0, time.UTC)
+		if date.Before(begin) || !date.Before(end) {
+			continue
+		}
+		if (req.QueryInvalid == "1" && !inv.Invalid) || (req.QueryInvalid == "0" && inv.Invalid) {
+			continue
+		}
+		matched = append(matched, inv)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].InvoiceNo < matched[j].InvoiceNo
+	})
+	
+	list := []map[string]interface{}{}
+	for i := (req.ShowingPage - 1) * req.NumPerPage; i < len(matched) && len(list) < req.NumPerPage; i++ {
+		list = append(list, invoiceFields(matched[i]))
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["TotalCount"] = len(matched)
+	resp["ShowingPage"] = req.ShowingPage
+	resp["InvoiceData"] = list
+	return resp
+}
+
+// getInvalid 查詢作廢發票
+func (s *Server) getInvalid(data []byte) interface{} {
+	inv, ok := s.lookup(data)
+	if !ok || !inv.Invalid {
+		return result(RtnCodeNotFound, "查無作廢資料")
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["II_Invoice_No"] = inv.InvoiceNo
+	resp["II_Date"] = inv.InvalidDate.Format(dateTimeLayout)
+	resp["II_Upload_Status"] = ecpay.UploadStatusNo
+	resp["II_Buyer_Identifier"] = inv.CustomerIdentifier
+	resp["Reason"] = inv.InvalidReason
+	return resp
+}
+
+// allowance 開立折讓
+func (s *Server) allowance(data []byte) interface{} {
+	var req ecpay.AllowanceRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	return s.createAllowance(&req, false)
+}
+
+// allowanceByCollegiate 開立線上折讓，消費者同意前可以 AllowanceInvalidByCollegiate 取消
+func (s *Server) allowanceByCollegiate(data []byte) interface{} {
+	var req ecpay.AllowanceByCollegiateRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	if req.ReturnURL == "" {
+		return result(RtnCodeFailed, "ReturnURL 不能為空")
+	}
+	
+	return s.createAllowance(&req.AllowanceRequest, true)
+}
+
+// createAllowance 保存折讓並扣除發票剩餘可折讓金額
+func (s *Server) createAllowance(req *ecpay.AllowanceRequest, collegiate bool) interface{} {
+	inv, ok := s.invoices[req.InvoiceNo]
+	if !ok {
+		return result(RtnCodeNotFound, "查無發票資料")
+	}
+	
+	if inv.Invalid {
+		return result(RtnCodeFailed, "發票已作廢")
+	}
+	
+	if req.AllowanceAmount > inv.RemainingAllowance {
+		return result(RtnCodeFailed, "折讓金額超過剩餘可折讓金額")
+	}
+	
+	now := s.now()
+	a := &Allowance{
+		AllowanceNo:   fmt.Sprintf("%s%08d", now.Format("20060102"), s.nextAllow),
+		InvoiceNo:     inv.InvoiceNo,
+		AllowanceDate: now,
+		Amount:        req.AllowanceAmount,
+		CustomerName:  req.CustomerName,
+		Items:         req.Items,
+		Collegiate:    collegiate,
+	}
+	s.nextAllow++
+	s.allowances[a.AllowanceNo] = a
+	inv.RemainingAllowance -= a.Amount
+	
+	resp := result(RtnCodeSuccess, "開立折讓成功")
+	resp["IA_Allow_No"] = a.AllowanceNo
+	resp["IA_Invoice_No"] = a.InvoiceNo
+	resp["IA_Date"] = a.AllowanceDate.Format(dateTimeLayout)
+	resp["IA_Remain_Allowance_Amt"] = inv.RemainingAllowance
+	return resp
+}
+
+// allowanceInvalid 作廢折讓
+func (s *Server) allowanceInvalid(data []byte) interface{} {
+	var req ecpay.InvalidAllowanceRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	return s.invalidateAllowance(req.InvoiceNo, req.AllowanceNo, req.Reason, false)
+}
+
+// allowanceInvalidByCollegiate 取消線上折讓
+func (s *Server) allowanceInvalidByCollegiate(data []byte) interface{} {
+	var req ecpay.CancelAllowanceRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	return s.invalidateAllowance(req.InvoiceNo, req.AllowanceNo, req.Reason, true)
+}
+
+// invalidateAllowance 作廢或取消折讓並退回發票剩餘可折讓金額
+func (s *Server) invalidateAllowance(invoiceNo, allowanceNo, reason string, collegiate bool) interface{} {
+	a, ok := s.allowances[allowanceNo]
+	if !ok || a.InvoiceNo != invoiceNo || a.Collegiate != collegiate {
+		return result(RtnCodeNotFound, "查無折讓資料")
+	}
+	
+	if a.Invalid {
+		return result(RtnCodeFailed, "折讓已作廢")
+	}
+	
+	a.Invalid = true
+	a.InvalidDate = s.now()
+	a.InvalidReason = reason
+	if inv, ok := s.invoices[a.InvoiceNo]; ok {
+		inv.RemainingAllowance += a.Amount
+	}
+	
+	resp := result(RtnCodeSuccess, "作廢折讓成功")
+	resp["IA_Invoice_No"] = a.InvoiceNo
+	return resp
+}
+
+// getAllowanceList 查詢折讓明細
+func (s *Server) getAllowanceList(data []byte) interface{} {
+	var req ecpay.GetAllowanceListRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	var matched []*Allowance
+	for _, a := range s.allowances {
+		if (req.AllowanceNo != "" && a.AllowanceNo == req.AllowanceNo) ||
+			(req.AllowanceNo == "" && a.InvoiceNo == req.InvoiceNo) {
+			matched = append(matched, a)
+		}
+	}
+	if len(matched) == 0 {
+		return result(RtnCodeNotFound, "查無折讓資料")
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].AllowanceNo < matched[j].AllowanceNo
+	})
+	
+	list := []map[string]interface{}{}
+	for _, a := range matched {
+		invalidStatus := "0"
+		if a.Invalid {
+			invalidStatus = "1"
+		}
+		remaining := 0
+		if inv, ok := s.invoices[a.InvoiceNo]; ok {
+			remaining = inv.RemainingAllowance
+		}
+		list = append(list, map[string]interface{}{
+			"IA_Allow_No":             a.AllowanceNo,
+			"IA_Invoice_No":           a.InvoiceNo,
+			"IA_Date":                 a.AllowanceDate.Format(dateTimeLayout),
+			"IA_Customer_Name":        a.CustomerName,
+			"IA_Total_Amount":         a.Amount,
+			"IA_Remain_Allowance_Amt": remaining,
+			"IA_Invalid_Status":       invalidStatus,
+			"IA_Upload_Status":        ecpay.UploadStatusNo,
+			"Items":                   a.Items,
+		})
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["AllowanceInfo"] = list
+	return resp
+}
+
+// getAllowanceInvalid 查詢作廢折讓
+func (s *Server) getAllowanceInvalid(data []byte) interface{} {
+	var req ecpay.GetAllowanceInvalidRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	a, ok := s.allowances[req.AllowanceNo]
+	if !ok || a.InvoiceNo != req.InvoiceNo || !a.Invalid {
+		return result(RtnCodeNotFound, "查無作廢折讓資料")
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["AI_Allow_No"] = a.AllowanceNo
+	resp["AI_Invoice_No"] = a.InvoiceNo
+	resp["AI_Date"] = a.InvalidDate.Format(dateTimeLayout)
+	resp["AI_Upload_Status"] = ecpay.UploadStatusNo
+	resp["Reason"] = a.InvalidReason
+	return resp
+}
\ No newline at end of file
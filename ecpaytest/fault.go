@@ -0,0 +1,131 @@
+package ecpaytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// FaultKind 模擬故障類型
+type FaultKind int
+
+const (
+	// FaultDelay 延遲 Delay 後才處理請求並回應，搭配 Client.SetTimeout 模擬逾時；
+	// 若客戶端在延遲期間放棄，請求不會被處理
+	FaultDelay FaultKind = iota + 1
+	// FaultTransCode 不處理請求，回傳 TransCode 為 Code 的封包錯誤
+	FaultTransCode
+	// FaultRtnCode 不處理請求，回傳 RtnCode 為 Code 的業務錯誤
+	FaultRtnCode
+	// FaultBadBase64 處理請求後，回應 Data 為非法的 Base64 字串
+	FaultBadBase64
+	// FaultBadCipher 處理請求後，回應 Data 為長度錯誤而無法解密的密文
+	FaultBadCipher
+	// FaultTruncate 處理請求後，回應本文只送出一半即中斷連線
+	FaultTruncate
+	// FaultLateResponse 處理請求後，等到客戶端放棄才回應，模擬「已開立但未收到結果」
+	FaultLateResponse
+)
+
+// Fault 模擬故障設定
+type Fault struct {
+	Kind  FaultKind
+	Delay time.Duration // FaultDelay 的延遲時間
+	Code  int           // FaultTransCode / FaultRtnCode 回傳的代碼
+	Msg   string        // FaultTransCode / FaultRtnCode 回傳的訊息
+}
+
+// faultKey 故障設定的索引，call 為 0 表示該 API 的每一次呼叫
+type faultKey struct {
+	apiPath string
+	call    int
+}
+
+// InjectFault 設定 API 第 call 次呼叫（從 1 開始）時發生的故障，call 為 0 表示每一次呼叫
+// 指定次數的設定優先於 call 為 0 的設定
+func (s *Server) InjectFault(apiPath string, call int, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[faultKey{apiPath, call}] = fault
+}
+
+// ClearFaults 清除所有故障設定
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[faultKey]Fault)
+}
+
+// fault 取得 API 第 call 次呼叫的故障設定，呼叫端需持有 s.mu
+func (s *Server) fault(apiPath string, call int) (Fault, bool) {
+	if f, ok := s.faults[faultKey{apiPath, call}]; ok {
+		return f, true
+	}
+	f, ok := s.faults[faultKey{apiPath, 0}]
+	return f, ok
+}
+
+// serveFault 依故障設定回應請求
+func (s *Server) serveFault(w http.ResponseWriter, r *http.Request, body []byte, f Fault) {
+	switch f.Kind {
+	case FaultDelay:
+		if !s.wait(r, f.Delay) {
+			return
+		}
+		s.serve(w, r.URL.Path, body)
+		
+	case FaultTransCode:
+		s.writeResponse(w, s.transError(f.Code, f.Msg))
+		
+	case FaultRtnCode:
+		s.writeResponse(w, s.success(result(f.Code, f.Msg)))
+		
+	case FaultBadBase64:
+		resp := s.handle(r.URL.Path, body)
+		resp.Data = "%%not-base64%%"
+		s.writeResponse(w, resp)
+		
+	case FaultBadCipher:
+		resp := s.handle(r.URL.Path, body)
+		if len(resp.Data) > 4 {
+			// 去掉最後一個 Base64 區段，密文長度不再是區塊大小的倍數
+			resp.Data = resp.Data[:len(resp.Data)-4]
+		}
+		s.writeResponse(w, resp)
+		
+	case FaultTruncate:
+		resp, _ := json.Marshal(s.handle(r.URL.Path, body))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(resp)))
+		w.Write(resp[:len(resp)/2])
+		
+	case FaultLateResponse:
+		resp := s.handle(r.URL.Path, body)
+		s.wait(r, 0)
+		// 客戶端已放棄，以下回應不會被收到
+		s.writeResponse(w, resp)
+		
+	default:
+		s.serve(w, r.URL.Path, body)
+	}
+}
+
+// wait 等待 d 時間（d 為 0 表示不限時），期間客戶端放棄或伺服器關閉時回傳 false
+func (s *Server) wait(r *http.Request, d time.Duration) bool {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	
+	select {
+	case <-timeout:
+		return true
+	case <-r.Context().Done():
+		return false
+	case <-s.closing:
+		return false
+	}
+}
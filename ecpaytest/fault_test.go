@@ -0,0 +1,48 @@
+package ecpaytest_test
+
+import (
+	"testing"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+func TestFaultErrorCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		fault  ecpaytest.Fault
+		code   ecpay.ErrorCode
+		issued bool // 伺服器是否已開立
+	}{
+		{"Delay", ecpaytest.Fault{Kind: ecpaytest.FaultDelay, Delay: time.Second}, ecpay.ErrCodeNetwork, false},
+		{"TransCode", ecpaytest.Fault{Kind: ecpaytest.FaultTransCode, Code: 9999, Msg: "系統維護中"}, ecpay.ErrCodeAPI, false},
+		{"RtnCode", ecpaytest.Fault{Kind: ecpaytest.FaultRtnCode, Code: ecpaytest.RtnCodeFailed, Msg: "資料錯誤"}, ecpay.ErrCodeAPI, false},
+		{"BadBase64", ecpaytest.Fault{Kind: ecpaytest.FaultBadBase64}, ecpay.ErrCodeCrypto, true},
+		{"BadCipher", ecpaytest.Fault{Kind: ecpaytest.FaultBadCipher}, ecpay.ErrCodeCrypto, true},
+		{"Truncate", ecpaytest.Fault{Kind: ecpaytest.FaultTruncate}, ecpay.ErrCodeResponse, true},
+		{"LateResponse", ecpaytest.Fault{Kind: ecpaytest.FaultLateResponse}, ecpay.ErrCodeNetwork, true},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := ecpaytest.NewServer()
+			defer server.Close()
+			
+			client, err := server.NewClient(ecpay.WithTimeout(200 * time.Millisecond))
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			
+			server.InjectFault("/B2CInvoice/Issue", 1, tt.fault)
+			_, err = client.IssueInvoice(issueRequest("R001"))
+			if !ecpay.IsError(err, tt.code) {
+				t.Fatalf("IssueInvoice err = %v, want %s", err, tt.code)
+			}
+			
+			if _, ok := server.InvoiceByRelateNumber("R001"); ok != tt.issued {
+				t.Errorf("issued = %v, want %v", ok, tt.issued)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,342 @@
+package ecpaytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+// Interaction 一次 API 呼叫的錄製內容
+// Envelope 為原始封包，Data 為解密後的 Data 欄位，方便閱讀與比對
+type Interaction struct {
+	APIPath  string          `json:"APIPath"`
+	Request  RecordedMessage `json:"Request"`
+	Response RecordedMessage `json:"Response"`
+}
+
+// RecordedMessage 錄製的請求或回應
+type RecordedMessage struct {
+	StatusCode int             `json:"StatusCode,omitempty"`
+	Envelope   json.RawMessage `json:"Envelope"`
+	Data       json.RawMessage `json:"Data,omitempty"`
+}
+
+// DefaultIgnoreFields 重播比對請求時預設忽略的欄位
+var DefaultIgnoreFields = []string{"Timestamp", "RelateNumber"}
+
+// Recorder 將每次 API 呼叫錄製為 golden 檔案的 http.RoundTripper
+// 每次呼叫寫成 dir 下的一個 JSON 檔，檔名依呼叫順序編號，例如 001_B2CInvoice_Issue.json
+type Recorder struct {
+	dir       string
+	crypto    *ecpay.CryptoHandler
+	transport http.RoundTripper
+	
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder 建立錄製用 RoundTripper，實際請求由 transport 發送，nil 時使用 http.DefaultTransport
+func NewRecorder(dir, hashKey, hashIV string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	
+	return &Recorder{
+		dir:       dir,
+		crypto:    ecpay.NewCryptoHandler(hashKey, hashIV),
+		transport: transport,
+	}
+}
+
+// RoundTrip 實作 http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	
+	interaction := Interaction{
+		APIPath: req.URL.Path,
+		Request: RecordedMessage{
+			Envelope: rawJSON(reqBody),
+			Data:     decryptEnvelope(r.crypto, reqBody),
+		},
+		Response: RecordedMessage{
+			StatusCode: resp.StatusCode,
+			Envelope:   rawJSON(respBody),
+			Data:       decryptEnvelope(r.crypto, respBody),
+		},
+	}
+	
+	if err := r.save(&interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save 寫出 golden 檔案
+func (r *Recorder) save(interaction *Interaction) error {
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+	
+	content, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ecpaytest: 編碼錄製內容失敗: %v", err)
+	}
+	
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("ecpaytest: 建立錄製目錄失敗: %v", err)
+	}
+	
+	name := fmt.Sprintf("%03d%s.json", seq, strings.ReplaceAll(interaction.APIPath, "/", "_"))
+	if err := os.WriteFile(filepath.Join(r.dir, name), append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("ecpaytest: 寫入錄製檔案失敗: %v", err)
+	}
+	return nil
+}
+
+// Replayer 以 golden 檔案回應請求的 http.RoundTripper
+// 依 API 路徑與解密後的請求 Data 比對錄製內容，忽略 IgnoreFields 指定的欄位；
+// 每筆錄製只會被使用一次，依檔名順序取第一筆符合者。
+// 回應的 Data 以錄製檔中解密後的內容重新加密，因此可直接編輯 golden 檔案
+type Replayer struct {
+	crypto *ecpay.CryptoHandler
+	
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+	ignore       map[string]bool
+}
+
+// NewReplayer 載入 dir 下的 golden 檔案並建立重播用 RoundTripper
+func NewReplayer(dir, hashKey, hashIV string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("ecpaytest: 讀取錄製目錄失敗: %v", err)
+	}
+	sort.Strings(files)
+	
+	r := &Replayer{
+		crypto: ecpay.NewCryptoHandler(hashKey, hashIV),
+	}
+	r.IgnoreFields(DefaultIgnoreFields...)
+	
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("ecpaytest: 讀取錄製檔案失敗: %v", err)
+		}
+		
+		var interaction Interaction
+		if err := json.Unmarshal(content, &interaction); err != nil {
+			return nil, fmt.Errorf("ecpaytest: 解析錄製檔案 %s 失敗: %v", filepath.Base(file), err)
+		}
+		r.interactions = append(r.interactions, &interaction)
+	}
+	r.used = make([]bool, len(r.interactions))
+	
+	return r, nil
+}
+
+// IgnoreFields 設定比對請求 Data 時忽略的欄位（取代原本的設定），適用於任何層級的同名欄位
+func (r *Replayer) IgnoreFields(fields ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	r.ignore = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		r.ignore[field] = true
+	}
+}
+
+// RoundTrip 實作 http.RoundTripper
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	data := r.normalize(decryptEnvelope(r.crypto, reqBody))
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.APIPath != req.URL.Path {
+			continue
+		}
+		if !reflect.DeepEqual(data, r.normalize(interaction.Request.Data)) {
+			continue
+		}
+		
+		r.used[i] = true
+		return r.response(req, interaction)
+	}
+	
+	return nil, fmt.Errorf("ecpaytest: 找不到符合的錄製內容: %s %s", req.URL.Path, compactJSON(decryptEnvelope(r.crypto, reqBody)))
+}
+
+// Unused 取得尚未被使用的錄製內容
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, *interaction)
+		}
+	}
+	return unused
+}
+
+// response 由錄製內容建立回應
+func (r *Replayer) response(req *http.Request, interaction *Interaction) (*http.Response, error) {
+	body := []byte(interaction.Response.Envelope)
+	var raw string
+	if json.Unmarshal(body, &raw) == nil {
+		// 錄製時回應不是 JSON，以字串保存
+		body = []byte(raw)
+	}
+	
+	if len(interaction.Response.Data) > 0 {
+		var envelope map[string]interface{}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, fmt.Errorf("ecpaytest: 解析錄製回應失敗: %v", err)
+		}
+		
+		encrypted, err := r.crypto.Encrypt(string(compactJSON(interaction.Response.Data)))
+		if err != nil {
+			return nil, fmt.Errorf("ecpaytest: 加密錄製回應失敗: %v", err)
+		}
+		envelope["Data"] = encrypted
+		
+		if body, err = json.Marshal(envelope); err != nil {
+			return nil, fmt.Errorf("ecpaytest: 編碼錄製回應失敗: %v", err)
+		}
+	}
+	
+	statusCode := interaction.Response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalize 解析 JSON 並移除忽略的欄位，呼叫端需持有 r.mu
+func (r *Replayer) normalize(data json.RawMessage) interface{} {
+	var v interface{}
+	if len(data) == 0 || json.Unmarshal(data, &v) != nil {
+		return string(data)
+	}
+	return r.strip(v)
+}
+
+// strip 遞迴移除忽略的欄位
+func (r *Replayer) strip(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if r.ignore[k] {
+				delete(val, k)
+				continue
+			}
+			val[k] = r.strip(child)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = r.strip(child)
+		}
+	}
+	return v
+}
+
+// readRequestBody 讀取請求本文
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ecpaytest: 讀取請求失敗: %v", err)
+	}
+	return body, nil
+}
+
+// decryptEnvelope 解密封包中的 Data 欄位，無法解密時回傳 nil
+func decryptEnvelope(crypto *ecpay.CryptoHandler, body []byte) json.RawMessage {
+	var envelope struct {
+		Data string `json:"Data"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Data == "" {
+		return nil
+	}
+	
+	plain, err := crypto.Decrypt(envelope.Data)
+	if err != nil || !json.Valid([]byte(plain)) {
+		return nil
+	}
+	
+	var indented bytes.Buffer
+	if json.Indent(&indented, []byte(plain), "", "  ") != nil {
+		return json.RawMessage(plain)
+	}
+	return indented.Bytes()
+}
+
+// rawJSON 合法 JSON 原樣保留，否則轉為 JSON 字串
+func rawJSON(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return compactJSON(body)
+	}
+	
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// compactJSON 移除 JSON 的空白
+func compactJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if json.Compact(&buf, data) != nil {
+		return data
+	}
+	return buf.Bytes()
+}
\ No newline at end of file
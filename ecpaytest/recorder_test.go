@@ -0,0 +1,115 @@
+package ecpaytest_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := ecpaytest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+	
+	// 錄製：經由 Recorder 向模擬伺服器開立並查詢
+	recorder := ecpaytest.NewRecorder(dir, server.HashKey, server.HashIV, nil)
+	client, err := server.NewClient(ecpay.WithTransport(recorder))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	issued := issue(t, client, "R001")
+	queried, err := client.GetIssue(&ecpay.GetIssueRequest{InvoiceNo: issued.InvoiceNo, InvoiceDate: issued.InvoiceDate[:10]})
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 || filepath.Base(files[0]) != "001_B2CInvoice_Issue.json" {
+		t.Fatalf("golden files = %v", files)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var golden ecpaytest.Interaction
+	if err := json.Unmarshal(content, &golden); err != nil {
+		t.Fatalf("golden file: %v", err)
+	}
+	if !strings.Contains(string(golden.Response.Data), issued.InvoiceNo) {
+		t.Errorf("golden response Data = %s, want the decrypted invoice", golden.Response.Data)
+	}
+	
+	// 重播：不連線模擬伺服器，回應與錄製時相同；RelateNumber 預設不比對
+	replayer, err := ecpaytest.NewReplayer(dir, server.HashKey, server.HashIV)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	client, err = server.NewClient(ecpay.WithTransport(replayer))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	calls := server.Calls("/B2CInvoice/Issue")
+	
+	replayed := issue(t, client, "R002")
+	if replayed.InvoiceNo != issued.InvoiceNo || replayed.RandomNumber != issued.RandomNumber {
+		t.Errorf("replayed Issue = %+v, want %+v", replayed, issued)
+	}
+	if server.Calls("/B2CInvoice/Issue") != calls {
+		t.Error("replay reached the server")
+	}
+	if unused := replayer.Unused(); len(unused) != 1 || unused[0].APIPath != "/B2CInvoice/GetIssue" {
+		t.Errorf("Unused = %+v, want the GetIssue recording", unused)
+	}
+	
+	inv, err := client.GetIssue(&ecpay.GetIssueRequest{InvoiceNo: issued.InvoiceNo, InvoiceDate: issued.InvoiceDate[:10]})
+	if err != nil {
+		t.Fatalf("replayed GetIssue: %v", err)
+	}
+	if inv.InvoiceNo != queried.InvoiceNo || inv.SalesAmount != queried.SalesAmount {
+		t.Errorf("replayed GetIssue = %+v, want %+v", inv, queried)
+	}
+}
+
+func TestReplayMiss(t *testing.T) {
+	server := ecpaytest.NewServer()
+	defer server.Close()
+	dir := t.TempDir()
+	
+	recorder := ecpaytest.NewRecorder(dir, server.HashKey, server.HashIV, nil)
+	client, err := server.NewClient(ecpay.WithTransport(recorder))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	issue(t, client, "R001")
+	
+	replayer, err := ecpaytest.NewReplayer(dir, server.HashKey, server.HashIV)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	client, err = server.NewClient(ecpay.WithTransport(replayer))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	
+	// 內容不同的請求找不到錄製
+	req := issueRequest("R001")
+	req.SalesAmount = "200"
+	req.Items[0].ItemPrice, req.Items[0].ItemAmount = 200, 200
+	if _, err := client.IssueInvoice(req); err == nil || !strings.Contains(err.Error(), "找不到符合的錄製內容") {
+		t.Fatalf("mismatched request err = %v, want a replay miss", err)
+	}
+	
+	// 每筆錄製只能使用一次
+	issue(t, client, "R001")
+	if _, err := client.IssueInvoice(issueRequest("R001")); err == nil || !strings.Contains(err.Error(), "找不到符合的錄製內容") {
+		t.Fatalf("second replay err = %v, want a replay miss", err)
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("Unused = %+v, want none", unused)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,263 @@
+// Package ecpaytest 提供測試用的綠界電子發票模擬伺服器
+package ecpaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+// 測試環境預設的商店代號與金鑰
+const (
+	DefaultMerchantID = "2000132"
+	DefaultHashKey    = "ejCk326UnaZWKisg"
+	DefaultHashIV     = "q9jcZX8Ib9LM8wYk"
+)
+
+// transCodeError 封包層錯誤時回傳的 TransCode
+const transCodeError = 9999999
+
+// Server 綠界電子發票模擬伺服器
+// 與正式 API 使用相同的封包格式：BaseRequest / BaseResponse 外層、AES 加密的 Data，
+// 並保存開立、作廢與折讓狀態，讓 Issue→GetIssue→Invalid 等流程與正式環境相同
+type Server struct {
+	*httptest.Server
+	
+	MerchantID string
+	HashKey    string
+	HashIV     string
+	
+	crypto *ecpay.CryptoHandler
+	routes map[string]routeFunc
+	
+	mu            sync.Mutex
+	now           func() time.Time
+	header        string
+	nextNumber    int
+	nextAllow     int
+	invoices      map[string]*Invoice        // InvoiceNo → 發票
+	relates       map[string]string          // RelateNumber → InvoiceNo
+	allowances    map[string]*Allowance      // AllowanceNo → 折讓
+	delayed       map[string]*delayedInvoice // Tsr → 延遲開立
+	customers     map[string]*B2BCustomer    // Identifier → 交易對象
+	b2bInvoices   map[string]*B2BInvoice     // InvoiceNumber → B2B 發票
+	b2bAllowances map[string]*B2BAllowance   // AllowanceNo → B2B 折讓
+	companies     map[string]string          // 統一編號 → 公司名稱
+	govWords      []ecpay.GovInvoiceWord     // 財政部配號結果
+	tracks        map[string]*Track          // TrackID → 字軌
+	nextTrack     int
+	calls         map[string]int     // API 路徑 → 呼叫次數
+	faults        map[faultKey]Fault // 故障設定
+	
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// routeFunc API 處理函式，data 為解密後的請求，回傳值會加密後放入回應 Data
+type routeFunc func(s *Server, data []byte) interface{}
+
+// NewServer 以測試環境預設金鑰建立並啟動模擬伺服器
+func NewServer() *Server {
+	return NewServerWithKeys(DefaultMerchantID, DefaultHashKey, DefaultHashIV)
+}
+
+// NewServerWithKeys 以指定的商店代號與金鑰建立並啟動模擬伺服器
+func NewServerWithKeys(merchantID, hashKey, hashIV string) *Server {
+	s := &Server{
+		MerchantID:    merchantID,
+		HashKey:       hashKey,
+		HashIV:        hashIV,
+		crypto:        ecpay.NewCryptoHandler(hashKey, hashIV),
+		now:           time.Now,
+		header:        "AB",
+		nextNumber:    10000000,
+		nextAllow:     1,
+		invoices:      make(map[string]*Invoice),
+		relates:       make(map[string]string),
+		allowances:    make(map[string]*Allowance),
+		delayed:       make(map[string]*delayedInvoice),
+		tracks:        make(map[string]*Track),
+		customers:     make(map[string]*B2BCustomer),
+		b2bInvoices:   make(map[string]*B2BInvoice),
+		b2bAllowances: make(map[string]*B2BAllowance),
+		companies:     make(map[string]string),
+		calls:         make(map[string]int),
+		faults:        make(map[faultKey]Fault),
+		closing:       make(chan struct{}),
+	}
+	s.routes = b2cRoutes()
+	for _, routes := range []map[string]routeFunc{trackRoutes(), b2bRoutes()} {
+		for apiPath, route := range routes {
+			s.routes[apiPath] = route
+		}
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Environment 指向模擬伺服器的環境設定
+func (s *Server) Environment() ecpay.Environment {
+	return ecpay.Environment(s.URL)
+}
+
+// NewClient 建立指向模擬伺服器的客戶端
+func (s *Server) NewClient(opts ...ecpay.Option) (*ecpay.Client, error) {
+	return ecpay.NewClient(s.MerchantID, s.HashKey, s.HashIV, s.Environment(), opts...)
+}
+
+// SetNow 設定模擬伺服器的時間來源
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Calls 取得 API 被呼叫的次數
+func (s *Server) Calls(apiPath string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[apiPath]
+}
+
+// Close 關閉模擬伺服器，尚在延遲中的請求會立即結束
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+	})
+	s.Server.Close()
+}
+
+// ServeHTTP 實作 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	s.mu.Lock()
+	s.calls[r.URL.Path]++
+	fault, hasFault := s.fault(r.URL.Path, s.calls[r.URL.Path])
+	s.mu.Unlock()
+	
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if hasFault {
+		s.serveFault(w, r, body, fault)
+		return
+	}
+	
+	s.serve(w, r.URL.Path, body)
+}
+
+// pdfFile 直接以檔案回傳的 API 結果（發票 PDF），不包成加密封包
+type pdfFile []byte
+
+// serve 呼叫對應的 API 並寫出回應
+func (s *Server) serve(w http.ResponseWriter, apiPath string, body []byte) {
+	data, errResp := s.dispatch(apiPath, body)
+	if errResp != nil {
+		s.writeResponse(w, errResp)
+		return
+	}
+	
+	if pdf, ok := data.(pdfFile); ok {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf)
+		return
+	}
+	s.writeResponse(w, s.success(data))
+}
+
+// handle 解析請求封包並呼叫對應的 API，回傳回應封包
+func (s *Server) handle(apiPath string, body []byte) *ecpay.BaseResponse {
+	data, errResp := s.dispatch(apiPath, body)
+	if errResp != nil {
+		return errResp
+	}
+	return s.success(data)
+}
+
+// dispatch 解析請求封包並呼叫對應的 API，回傳 API 結果；封包錯誤時回傳 TransCode 錯誤的回應封包
+func (s *Server) dispatch(apiPath string, body []byte) (interface{}, *ecpay.BaseResponse) {
+	var req ecpay.BaseRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, s.transError(transCodeError, fmt.Sprintf("請求格式錯誤: %v", err))
+	}
+	
+	if req.MerchantID != s.MerchantID {
+		return nil, s.transError(transCodeError, "MerchantID 不正確")
+	}
+	
+	data, err := s.crypto.Decrypt(req.Data)
+	if err != nil || !json.Valid([]byte(data)) {
+		return nil, s.transError(transCodeError, "Data 解密失敗")
+	}
+	
+	route, ok := s.routes[apiPath]
+	if !ok {
+		return result(0, fmt.Sprintf("ecpaytest: 不支援的 API %s", apiPath)), nil
+	}
+	
+	s.mu.Lock()
+	resp := route(s, []byte(data))
+	s.mu.Unlock()
+	
+	return resp, nil
+}
+
+// success 建立 TransCode 為 1 的回應封包
+func (s *Server) success(data interface{}) *ecpay.BaseResponse {
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return s.transError(transCodeError, fmt.Sprintf("回應編碼失敗: %v", err))
+	}
+	
+	encrypted, err := s.crypto.Encrypt(string(plain))
+	if err != nil {
+		return s.transError(transCodeError, fmt.Sprintf("回應加密失敗: %v", err))
+	}
+	
+	resp := &ecpay.BaseResponse{
+		MerchantID: s.MerchantID,
+		TransCode:  1,
+		TransMsg:   "Success",
+		Data:       encrypted,
+	}
+	resp.RpHeader.Timestamp = time.Now().Unix()
+	return resp
+}
+
+// transError 建立 TransCode 錯誤的回應封包
+func (s *Server) transError(code int, msg string) *ecpay.BaseResponse {
+	resp := &ecpay.BaseResponse{
+		MerchantID: s.MerchantID,
+		TransCode:  code,
+		TransMsg:   msg,
+	}
+	resp.RpHeader.Timestamp = time.Now().Unix()
+	return resp
+}
+
+// writeResponse 寫出回應封包
+func (s *Server) writeResponse(w http.ResponseWriter, resp *ecpay.BaseResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// result 建立含 RtnCode 與 RtnMsg 的回應資料
+func result(code int, msg string) map[string]interface{} {
+	return map[string]interface{}{
+		"RtnCode": code,
+		"RtnMsg":  msg,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,382 @@
+package ecpaytest_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+// newServer 建立模擬伺服器與客戶端
+func newServer(t *testing.T) (*ecpaytest.Server, *ecpay.Client) {
+	t.Helper()
+	
+	server := ecpaytest.NewServer()
+	t.Cleanup(server.Close)
+	
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return server, client
+}
+
+// issueRequest 建立測試用的發票請求
+func issueRequest(relateNumber string) *ecpay.IssueInvoiceRequest {
+	return &ecpay.IssueInvoiceRequest{
+		RelateNumber:  relateNumber,
+		CustomerName:  "測試客戶",
+		CustomerEmail: "test@example.com",
+		Print:         ecpay.PrintNo,
+		Donation:      ecpay.DonationNo,
+		TaxType:       ecpay.TaxTypeRegular,
+		SalesAmount:   "100",
+		InvType:       ecpay.InvTypeGeneral,
+		Vat:           ecpay.VatYes,
+		Items: []ecpay.Item{
+			{ItemName: "商品", ItemCount: 1, ItemWord: "個", ItemPrice: 100, ItemTaxType: ecpay.TaxTypeRegular, ItemAmount: 100},
+		},
+	}
+}
+
+// issue 開立測試用發票
+func issue(t *testing.T, client *ecpay.Client, relateNumber string) *ecpay.IssueInvoiceResponse {
+	t.Helper()
+	
+	resp, err := client.IssueInvoice(issueRequest(relateNumber))
+	if err != nil {
+		t.Fatalf("IssueInvoice %s: %v", relateNumber, err)
+	}
+	return resp
+}
+
+func TestDelayIssue(t *testing.T) {
+	server, client := newServer(t)
+	
+	delayRequest := func(tsr, relateNumber string) *ecpay.DelayIssueRequest {
+		return &ecpay.DelayIssueRequest{
+			IssueInvoiceRequest: *issueRequest(relateNumber),
+			DelayFlag:           ecpay.DelayFlagTrigger,
+			Tsr:                 tsr,
+			PayType:             ecpay.PayTypeECPay,
+			PayAct:              "ECPAY",
+		}
+	}
+	delay := func(tsr, relateNumber string) {
+		t.Helper()
+		if _, err := client.DelayIssue(delayRequest(tsr, relateNumber)); err != nil {
+			t.Fatalf("DelayIssue %s: %v", tsr, err)
+		}
+	}
+	
+	// 觸發後才開立，已開立的無法取消
+	delay("TSR001", "R001")
+	if _, ok := server.InvoiceByRelateNumber("R001"); ok {
+		t.Fatal("invoice issued before trigger")
+	}
+	if _, err := client.TriggerIssue(&ecpay.TriggerIssueRequest{Tsr: "TSR001", PayType: ecpay.PayTypeECPay}); err != nil {
+		t.Fatalf("TriggerIssue: %v", err)
+	}
+	if _, ok := server.InvoiceByRelateNumber("R001"); !ok {
+		t.Fatal("invoice not issued after trigger")
+	}
+	if _, err := client.CancelDelayIssue(&ecpay.CancelDelayIssueRequest{Tsr: "TSR001"}); !ecpay.IsError(err, ecpay.ErrCodeAPI) {
+		t.Errorf("CancelDelayIssue after trigger err = %v, want API error", err)
+	}
+	
+	// 取消後無法再觸發
+	delay("TSR002", "R002")
+	if _, err := client.CancelDelayIssue(&ecpay.CancelDelayIssueRequest{Tsr: "TSR002"}); err != nil {
+		t.Fatalf("CancelDelayIssue: %v", err)
+	}
+	if _, err := client.TriggerIssue(&ecpay.TriggerIssueRequest{Tsr: "TSR002", PayType: ecpay.PayTypeECPay}); !ecpay.IsError(err, ecpay.ErrCodeAPI) {
+		t.Errorf("TriggerIssue after cancel err = %v, want API error", err)
+	}
+	if _, ok := server.InvoiceByRelateNumber("R002"); ok {
+		t.Error("canceled invoice was issued")
+	}
+	
+	// 已使用的 Tsr 不送出
+	calls := server.Calls("/B2CInvoice/DelayIssue")
+	if _, err := client.DelayIssue(delayRequest("TSR001", "R003")); !ecpay.IsError(err, ecpay.ErrCodeValidation) {
+		t.Errorf("DelayIssue with used Tsr err = %v, want validation error", err)
+	}
+	if server.Calls("/B2CInvoice/DelayIssue") != calls {
+		t.Error("DelayIssue with used Tsr reached the server")
+	}
+	
+	// 綠界拒絕的 Tsr 可再使用
+	server.InjectFault("/B2CInvoice/DelayIssue", calls+1, ecpaytest.Fault{Kind: ecpaytest.FaultRtnCode, Code: ecpaytest.RtnCodeFailed, Msg: "資料錯誤"})
+	if _, err := client.DelayIssue(delayRequest("TSR003", "R003")); !ecpay.IsError(err, ecpay.ErrCodeAPI) {
+		t.Fatalf("DelayIssue err = %v, want API error", err)
+	}
+	delay("TSR003", "R003")
+}
+
+func TestVoidWithReIssue(t *testing.T) {
+	server, client := newServer(t)
+	original := issue(t, client, "R001")
+	
+	resp, err := client.VoidWithReIssue(&ecpay.VoidWithReIssueRequest{
+		Invalid: &ecpay.InvalidInvoiceRequest{
+			InvoiceNo:   original.InvoiceNo,
+			InvoiceDate: original.InvoiceDate[:10],
+			Reason:      "金額錯誤",
+		},
+		Issue: issueRequest("R001"),
+	})
+	if err != nil {
+		t.Fatalf("VoidWithReIssue: %v", err)
+	}
+	
+	if resp.Issue.InvoiceNo == "" || resp.Issue.InvoiceNo == original.InvoiceNo {
+		t.Fatalf("reissued InvoiceNo = %q, want a new number", resp.Issue.InvoiceNo)
+	}
+	if inv, _ := server.Invoice(original.InvoiceNo); !inv.Invalid || resp.Invalid.RtnCode != 1 {
+		t.Errorf("original invoice not voided, Invalid = %+v", resp.Invalid)
+	}
+	if inv, ok := server.InvoiceByRelateNumber("R001"); !ok || inv.InvoiceNo != resp.Issue.InvoiceNo {
+		t.Errorf("RelateNumber R001 → %+v, want %s", inv, resp.Issue.InvoiceNo)
+	}
+}
+
+func TestAllowanceByCollegiate(t *testing.T) {
+	server, client := newServer(t)
+	inv := issue(t, client, "R001")
+	
+	resp, err := client.AllowanceByCollegiate(&ecpay.AllowanceByCollegiateRequest{
+		AllowanceRequest: ecpay.AllowanceRequest{
+			InvoiceNo:       inv.InvoiceNo,
+			InvoiceDate:     inv.InvoiceDate[:10],
+			AllowanceNotify: ecpay.AllowanceNotifyEmail,
+			NotifyMail:      "test@example.com",
+			AllowanceAmount: 40,
+			Items: []ecpay.Item{
+				{ItemName: "商品", ItemCount: 1, ItemWord: "個", ItemPrice: 40, ItemTaxType: ecpay.TaxTypeRegular, ItemAmount: 40},
+			},
+		},
+		ReturnURL: "https://example.com/allowance",
+	})
+	if err != nil {
+		t.Fatalf("AllowanceByCollegiate: %v", err)
+	}
+	if resp.IARemainAllowanceAmount != 60 {
+		t.Errorf("remaining = %d, want 60", resp.IARemainAllowanceAmount)
+	}
+	
+	// 消費者同意前取消，剩餘可折讓金額恢復
+	_, err = client.CancelAllowance(&ecpay.CancelAllowanceRequest{
+		InvoiceNo:   inv.InvoiceNo,
+		AllowanceNo: resp.IAAllowNo,
+		Reason:      "消費者取消",
+	})
+	if err != nil {
+		t.Fatalf("CancelAllowance: %v", err)
+	}
+	if a, _ := server.Allowance(resp.IAAllowNo); !a.Invalid {
+		t.Error("allowance not canceled")
+	}
+	if got, _ := server.Invoice(inv.InvoiceNo); got.RemainingAllowance != 100 {
+		t.Errorf("remaining after cancel = %d, want 100", got.RemainingAllowance)
+	}
+}
+
+func TestB2BExchange(t *testing.T) {
+	server, client := newServer(t)
+	ctx := context.Background()
+	today := time.Now()
+	
+	_, err := client.B2BMaintainCustomer(&ecpay.B2BMaintainCustomerRequest{
+		Action:       "Add",
+		Identifier:   "22099131",
+		CompanyName:  "測試公司",
+		ExchangeMode: ecpay.B2BExchangeModeExchange,
+	})
+	if err != nil {
+		t.Fatalf("B2BMaintainCustomer: %v", err)
+	}
+	
+	// 交換模式的交易對象，開立後待對方確認
+	issued, err := client.B2BIssue(&ecpay.B2BIssueRequest{
+		RelateNumber:       "B001",
+		CustomerIdentifier: "22099131",
+		InvType:            ecpay.InvTypeGeneral,
+		TaxType:            ecpay.TaxTypeRegular,
+		SalesAmount:        1000,
+		TaxAmount:          50,
+		TotalAmount:        1050,
+		Items: []ecpay.B2BItem{
+			{ItemSeq: 1, ItemName: "商品", ItemCount: 10, ItemWord: "個", ItemPrice: 100, ItemAmount: 1000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("B2BIssue: %v", err)
+	}
+	invoiceDate := ecpay.FormatInvoiceDate(today)
+	
+	inv, err := client.B2BGetIssue(&ecpay.B2BGetIssueRequest{
+		InvoiceCategory: ecpay.B2BInvoiceCategorySales,
+		InvoiceNumber:   issued.InvoiceNumber,
+		InvoiceDate:     invoiceDate,
+	})
+	if err != nil {
+		t.Fatalf("B2BGetIssue: %v", err)
+	}
+	if !inv.IsPending() || inv.TotalAmount != 1050 || len(inv.Items) != 1 {
+		t.Errorf("B2BGetIssue = %+v, want pending 1050 with one item", inv)
+	}
+	
+	// 供應商開給我方的發票，確認一張、退回一張
+	server.ReceiveB2BInvoice(ecpaytest.B2BInvoice{InvoiceNumber: "CD12345678", SellerIdentifier: "04595257", TotalAmount: 525})
+	server.ReceiveB2BInvoice(ecpaytest.B2BInvoice{InvoiceNumber: "CD12345679", SellerIdentifier: "04595257", TotalAmount: 210})
+	
+	pending := func(category int) []ecpay.B2BInvoice {
+		t.Helper()
+		var list []ecpay.B2BInvoice
+		for inv, err := range client.B2BListExchange(ctx, &ecpay.B2BExchangeFilter{
+			InvoiceCategory: category,
+			BeginDate:       today,
+			EndDate:         today,
+			ExchangeStatus:  ecpay.B2BExchangeStatusPending,
+		}) {
+			if err != nil {
+				t.Fatalf("B2BListExchange: %v", err)
+			}
+			list = append(list, inv)
+		}
+		return list
+	}
+	if list := pending(ecpay.B2BInvoiceCategoryPurchase); len(list) != 2 {
+		t.Fatalf("pending purchase invoices = %d, want 2", len(list))
+	}
+	
+	_, err = client.B2BConfirm(&ecpay.B2BConfirmRequest{
+		Kind:          ecpay.B2BConfirmIssue,
+		InvoiceNumber: "CD12345678",
+		InvoiceDate:   invoiceDate,
+	})
+	if err != nil {
+		t.Fatalf("B2BConfirm: %v", err)
+	}
+	_, err = client.B2BReject(&ecpay.B2BRejectRequest{
+		Kind:          ecpay.B2BRejectIssue,
+		InvoiceNumber: "CD12345679",
+		InvoiceDate:   invoiceDate,
+		Reason:        "金額錯誤",
+	})
+	if err != nil {
+		t.Fatalf("B2BReject: %v", err)
+	}
+	if list := pending(ecpay.B2BInvoiceCategoryPurchase); len(list) != 0 {
+		t.Errorf("pending purchase invoices after confirm/reject = %+v, want none", list)
+	}
+	if got, _ := server.B2BInvoice("CD12345679"); got.ExchangeStatus != ecpay.B2BExchangeStatusRejected {
+		t.Errorf("rejected invoice ExchangeStatus = %q", got.ExchangeStatus)
+	}
+	
+	// 我方開出的折讓待對方確認，確認後狀態更新
+	allowance, err := client.B2BAllowance(&ecpay.B2BAllowanceRequest{
+		CustomerIdentifier: "22099131",
+		TaxAmount:          5,
+		TotalAmount:        100,
+		Items: []ecpay.B2BAllowanceItem{{
+			OriginalInvoiceNumber:  issued.InvoiceNumber,
+			OriginalInvoiceDate:    invoiceDate,
+			OriginalSequenceNumber: 1,
+			ItemName:               "商品",
+			ItemCount:              1,
+			ItemWord:               "個",
+			ItemPrice:              100,
+			ItemAmount:             100,
+			ItemTax:                5,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("B2BAllowance: %v", err)
+	}
+	_, err = client.B2BConfirm(&ecpay.B2BConfirmRequest{Kind: ecpay.B2BConfirmAllowance, AllowanceNo: allowance.AllowanceNo})
+	if err != nil {
+		t.Fatalf("B2BConfirm allowance: %v", err)
+	}
+	if got, _ := server.B2BAllowance(allowance.AllowanceNo); got.ExchangeStatus != ecpay.B2BExchangeStatusConfirmed {
+		t.Errorf("allowance ExchangeStatus = %q, want confirmed", got.ExchangeStatus)
+	}
+	
+	// 作廢後查詢為作廢狀態
+	_, err = client.B2BInvalid(&ecpay.B2BInvalidRequest{
+		InvoiceNumber: issued.InvoiceNumber,
+		InvoiceDate:   invoiceDate,
+		Reason:        "訂單取消",
+	})
+	if err != nil {
+		t.Fatalf("B2BInvalid: %v", err)
+	}
+	inv, err = client.B2BGetIssue(&ecpay.B2BGetIssueRequest{
+		InvoiceCategory: ecpay.B2BInvoiceCategorySales,
+		InvoiceNumber:   issued.InvoiceNumber,
+		InvoiceDate:     invoiceDate,
+	})
+	if err != nil {
+		t.Fatalf("B2BGetIssue after invalid: %v", err)
+	}
+	if !inv.IsInvalid() {
+		t.Errorf("Status = %q, want invalid", inv.Status)
+	}
+}
+
+func TestDownloadInvoicePDF(t *testing.T) {
+	_, client := newServer(t)
+	
+	issued := issue(t, client, "R001")
+	var buf bytes.Buffer
+	n, err := client.DownloadInvoicePDF(&ecpay.InvoicePrintRequest{
+		InvoiceNo:   issued.InvoiceNo,
+		InvoiceDate: issued.InvoiceDate[:10],
+		PrintStyle:  ecpay.PrintStyleA4,
+	}, &buf)
+	if err != nil {
+		t.Fatalf("DownloadInvoicePDF: %v", err)
+	}
+	if n != int64(buf.Len()) || !strings.HasPrefix(buf.String(), "%PDF-") || !strings.Contains(buf.String(), issued.InvoiceNo) {
+		t.Errorf("PDF = %d bytes %q", n, buf.String())
+	}
+	
+	// 查無發票時回傳 JSON 封包，不寫入 w
+	buf.Reset()
+	_, err = client.DownloadInvoicePDF(&ecpay.InvoicePrintRequest{
+		InvoiceNo:   "ZZ99999999",
+		InvoiceDate: issued.InvoiceDate[:10],
+		PrintStyle:  ecpay.PrintStyleA4,
+	}, &buf)
+	if !ecpay.IsError(err, ecpay.ErrCodeAPI) || buf.Len() != 0 {
+		t.Errorf("DownloadInvoicePDF unknown invoice err = %v, wrote %d bytes", err, buf.Len())
+	}
+}
+
+func TestGetCompanyNameByTaxID(t *testing.T) {
+	server, client := newServer(t)
+	server.AddCompany("22099131", "測試公司")
+	
+	name, err := client.GetCompanyNameByTaxID("22099131")
+	if err != nil || name != "測試公司" {
+		t.Fatalf("GetCompanyNameByTaxID = %q, %v", name, err)
+	}
+	
+	// 綠界查無資料與 API 錯誤區分
+	if _, err := client.GetCompanyNameByTaxID("04595257"); !ecpay.IsError(err, ecpay.ErrCodeNotFound) {
+		t.Errorf("unknown tax ID err = %v, want not found", err)
+	}
+	
+	// 檢查碼錯誤不送出
+	calls := server.Calls("/B2CInvoice/GetCompanyNameByTaxID")
+	if _, err := client.GetCompanyNameByTaxID("12345678"); !ecpay.IsError(err, ecpay.ErrCodeValidation) {
+		t.Errorf("invalid tax ID err = %v, want validation error", err)
+	}
+	if server.Calls("/B2CInvoice/GetCompanyNameByTaxID") != calls {
+		t.Error("invalid tax ID reached the server")
+	}
+}
\ No newline at end of file
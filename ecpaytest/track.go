@@ -0,0 +1,234 @@
+package ecpaytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+// Track 模擬伺服器保存的字軌
+type Track struct {
+	TrackID       string
+	Period        ecpay.InvoicePeriod
+	InvType       string
+	InvoiceHeader string
+	Start         int // 起始號碼
+	End           int // 結束號碼（含）
+	UseStatus     int // ecpay.WordUseStatus*
+}
+
+// AddGovInvoiceWord 設定財政部配號結果，字軌只能在配號區間內新增
+func (s *Server) AddGovInvoiceWord(word ecpay.GovInvoiceWord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.govWords = append(s.govWords, word)
+}
+
+// Track 取得字軌目前狀態
+func (s *Server) Track(trackID string) (Track, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	t, ok := s.tracks[trackID]
+	if !ok {
+		return Track{}, false
+	}
+	return *t, true
+}
+
+// trackRoutes 字軌 API 路由
+func trackRoutes() map[string]routeFunc {
+	return map[string]routeFunc{
+		"/B2CInvoice/GetGovInvoiceWordSetting": (*Server).getGovInvoiceWordSetting,
+		"/B2CInvoice/AddInvoiceWordSetting":    (*Server).addInvoiceWordSetting,
+		"/B2CInvoice/UpdateInvoiceWordStatus":  (*Server).updateInvoiceWordStatus,
+		"/B2CInvoice/GetInvoiceWordSetting":    (*Server).getInvoiceWordSetting,
+	}
+}
+
+// getGovInvoiceWordSetting 查詢財政部配號結果
+func (s *Server) getGovInvoiceWordSetting(data []byte) interface{} {
+	var req struct {
+		InvoiceYear string `json:"InvoiceYear"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	list := []map[string]interface{}{}
+	for _, w := range s.govWords {
+		if strconv.Itoa(w.Period.Year) != req.InvoiceYear {
+			continue
+		}
+		list = append(list, map[string]interface{}{
+			"InvoiceTerm":   w.Period.Term,
+			"InvType":       w.InvType,
+			"InvoiceHeader": w.InvoiceHeader,
+			"InvoiceStart":  w.InvoiceStart,
+			"InvoiceEnd":    w.InvoiceEnd,
+			"Number":        w.Number,
+		})
+	}
+	if len(list) == 0 {
+		return result(RtnCodeNotFound, "查無配號資料")
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["InvoiceInfo"] = list
+	return resp
+}
+
+// addInvoiceWordSetting 新增字軌，區間須在財政部配號內且不可與既有字軌重疊
+func (s *Server) addInvoiceWordSetting(data []byte) interface{} {
+	var req struct {
+		InvoiceTerm   int    `json:"InvoiceTerm"`
+		InvoiceYear   string `json:"InvoiceYear"`
+		InvType       string `json:"InvType"`
+		InvoiceHeader string `json:"InvoiceHeader"`
+		InvoiceStart  string `json:"InvoiceStart"`
+		InvoiceEnd    string `json:"InvoiceEnd"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	year, _ := strconv.Atoi(req.InvoiceYear)
+	t := &Track{
+		Period:        ecpay.InvoicePeriod{Year: year, Term: req.InvoiceTerm},
+		InvType:       req.InvType,
+		InvoiceHeader: req.InvoiceHeader,
+		UseStatus:     ecpay.WordUseStatusUnused,
+	}
+	var err1, err2 error
+	t.Start, err1 = strconv.Atoi(req.InvoiceStart)
+	t.End, err2 = strconv.Atoi(req.InvoiceEnd)
+	if err1 != nil || err2 != nil || t.End < t.Start {
+		return result(RtnCodeFailed, "發票號碼區間錯誤")
+	}
+	
+	allocated := false
+	for _, w := range s.govWords {
+		start, _ := strconv.Atoi(w.InvoiceStart)
+		end, _ := strconv.Atoi(w.InvoiceEnd)
+		if w.Period == t.Period && w.InvType == t.InvType && w.InvoiceHeader == t.InvoiceHeader &&
+			t.Start >= start && t.End <= end {
+			allocated = true
+			break
+		}
+	}
+	if !allocated {
+		return result(RtnCodeFailed, "字軌區間不在財政部配號範圍內")
+	}
+	
+	for _, other := range s.tracks {
+		if other.Period == t.Period && other.InvoiceHeader == t.InvoiceHeader &&
+			t.Start <= other.End && other.Start <= t.End {
+			return result(RtnCodeFailed, "字軌區間與既有字軌重疊")
+		}
+	}
+	
+	s.nextTrack++
+	t.TrackID = strconv.Itoa(s.nextTrack)
+	s.tracks[t.TrackID] = t
+	
+	resp := result(RtnCodeSuccess, "新增成功")
+	resp["TrackID"] = t.TrackID
+	return resp
+}
+
+// updateInvoiceWordStatus 設定字軌號碼狀態
+func (s *Server) updateInvoiceWordStatus(data []byte) interface{} {
+	var req struct {
+		TrackID       string `json:"TrackID"`
+		InvoiceStatus int    `json:"InvoiceStatus"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	t, ok := s.tracks[req.TrackID]
+	if !ok {
+		return result(RtnCodeNotFound, "查無字軌資料")
+	}
+	
+	switch ecpay.InvoiceWordStatus(req.InvoiceStatus) {
+	case ecpay.WordStatusDisable:
+		t.UseStatus = ecpay.WordUseStatusDisabled
+	case ecpay.WordStatusPause:
+		t.UseStatus = ecpay.WordUseStatusPaused
+	case ecpay.WordStatusEnable:
+		t.UseStatus = ecpay.WordUseStatusInUse
+	default:
+		return result(RtnCodeFailed, "字軌狀態錯誤")
+	}
+	
+	return result(RtnCodeSuccess, "設定成功")
+}
+
+// getInvoiceWordSetting 查詢字軌
+func (s *Server) getInvoiceWordSetting(data []byte) interface{} {
+	var req struct {
+		InvoiceYear   string `json:"InvoiceYear"`
+		InvoiceTerm   int    `json:"InvoiceTerm"`
+		UseStatus     int    `json:"UseStatus"`
+		InvType       string `json:"InvType"`
+		InvoiceHeader string `json:"InvoiceHeader"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return result(RtnCodeFailed, fmt.Sprintf("資料格式錯誤: %v", err))
+	}
+	
+	var matched []*Track
+	for _, t := range s.tracks {
+		if strconv.Itoa(t.Period.Year) != req.InvoiceYear ||
+			(req.InvoiceTerm != 0 && t.Period.Term != req.InvoiceTerm) ||
+			(req.UseStatus != ecpay.WordUseStatusAll && t.UseStatus != req.UseStatus) ||
+			(req.InvType != "" && t.InvType != req.InvType) ||
+			(req.InvoiceHeader != "" && t.InvoiceHeader != req.InvoiceHeader) {
+			continue
+		}
+		matched = append(matched, t)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Start < matched[j].Start
+	})
+	
+	list := []map[string]interface{}{}
+	for _, t := range matched {
+		list = append(list, map[string]interface{}{
+			"TrackID":       t.TrackID,
+			"InvoiceYear":   t.Period.Year,
+			"InvoiceTerm":   t.Period.Term,
+			"InvType":       t.InvType,
+			"InvoiceHeader": t.InvoiceHeader,
+			"InvoiceStart":  fmt.Sprintf("%08d", t.Start),
+			"InvoiceEnd":    fmt.Sprintf("%08d", t.End),
+			"UseStatus":     t.UseStatus,
+		})
+	}
+	
+	resp := result(RtnCodeSuccess, "查詢成功")
+	resp["InvoiceInfo"] = list
+	return resp
+}
+
+// trackOf 取得發票號碼所屬的字軌
+func (s *Server) trackOf(invoiceNo string) (*Track, bool) {
+	if len(invoiceNo) != 10 {
+		return nil, false
+	}
+	number, err := strconv.Atoi(invoiceNo[2:])
+	if err != nil {
+		return nil, false
+	}
+	
+	for _, t := range s.tracks {
+		if t.InvoiceHeader == invoiceNo[:2] && number >= t.Start && number <= t.End {
+			return t, true
+		}
+	}
+	return nil, false
+}
\ No newline at end of file
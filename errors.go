@@ -1,6 +1,10 @@
 package ecpay
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 // ErrorCode 錯誤代碼
 type ErrorCode string
@@ -13,12 +17,19 @@ const (
 	ErrCodeParse      ErrorCode = "PARSE_ERROR"
 	ErrCodeAPI        ErrorCode = "API_ERROR"
 	ErrCodeCrypto     ErrorCode = "CRYPTO_ERROR"
+	ErrCodeNotFound   ErrorCode = "NOT_FOUND"
+	ErrCodeCanceled   ErrorCode = "CANCELED"
+	ErrCodeDeadline   ErrorCode = "DEADLINE_EXCEEDED"
 )
 
 // Error 自定義錯誤
 type Error struct {
 	Code    ErrorCode
 	Message string
+	Err     error // 原始錯誤（可能為 nil）
+	
+	// Attempts 啟用重試時的總嘗試次數，未啟用時為 0
+	Attempts int
 }
 
 // NewError 建立新的錯誤
@@ -31,13 +42,37 @@ func NewError(code ErrorCode, message string) *Error {
 
 // Error 實作 error 介面
 func (e *Error) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("[%s] %s (已嘗試 %d 次)", e.Code, e.Message, e.Attempts)
+	}
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
 
+// Unwrap 回傳原始錯誤，讓 errors.Is 可判斷 context.Canceled 等錯誤
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // IsError 檢查是否為特定錯誤類型
 func IsError(err error, code ErrorCode) bool {
-	if e, ok := err.(*Error); ok {
+	var e *Error
+	if errors.As(err, &e) {
 		return e.Code == code
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// contextError 將 context 錯誤轉換為對應的錯誤代碼
+func contextError(err error) *Error {
+	code := ErrCodeCanceled
+	message := "請求已取消"
+	if errors.Is(err, context.DeadlineExceeded) {
+		code = ErrCodeDeadline
+		message = "請求超過期限"
+	}
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf("%s: %v", message, err),
+		Err:     err,
+	}
+}
@@ -10,12 +10,17 @@ import (
 
 func main() {
 	// 建立客戶端 (使用測試環境)
-	client := ecpay.NewClient(
+	client, err := ecpay.NewClient(
 		"2000132",           // 測試商店代號
 		"ejCk326UnaZWKisg",  // 測試 HashKey (16碼)
 		"q9jcZX8Ib9LM8wYk",  // 測試 HashIV (16碼)
 		ecpay.Stage,         // 測試環境
+		ecpay.WithTimeout(30*time.Second),
+		ecpay.WithUserAgent("ecpay-invoice-example/1.0"),
 	)
+	if err != nil {
+		log.Fatal("建立客戶端失敗:", err)
+	}
 	
 	// 啟用除錯模式
 	client.SetDebug(true)
@@ -1,3 +1,10 @@
 module github.com/YiChien-everlink/ecpay-invoice-sdk
 
-go 1.25.0
\ No newline at end of file
+go 1.25.0
+
+require (
+	go.opentelemetry.io/otel v1.44.0
+	go.opentelemetry.io/otel/trace v1.44.0
+)
+
+require github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -1,24 +1,57 @@
 package ecpay
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
 
 // IssueInvoice 開立發票
 func (c *Client) IssueInvoice(req *IssueInvoiceRequest) (*IssueInvoiceResponse, error) {
+	return c.IssueInvoiceContext(context.Background(), req)
+}
+
+// IssueInvoiceContext 開立發票（可透過 ctx 取消或設定期限）
+func (c *Client) IssueInvoiceContext(ctx context.Context, req *IssueInvoiceRequest) (_ *IssueInvoiceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "IssueInvoice", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
 	// 驗證請求
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
 	
+	// 向綠界驗證載具與捐贈碼
+	if err := c.verifyInvoiceCodes(ctx, req); err != nil {
+		return nil, err
+	}
+	
 	// 設定商品序號
 	for i := range req.Items {
 		req.Items[i].ItemSeq = i + 1
 	}
 	
+	// 啟用重試時，重送前會先以 RelateNumber 確認前次是否已開立
+	var resp *IssueInvoiceResponse
+	if c.retry != nil {
+		resp, err = c.issueWithRetry(ctx, req)
+	} else {
+		resp, err = c.issueInvoice(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	span.SetAttributes(attrInvoiceNo.String(resp.InvoiceNo))
+	return resp, nil
+}
+
+// issueInvoice 發送開立發票請求
+func (c *Client) issueInvoice(ctx context.Context, req *IssueInvoiceRequest) (*IssueInvoiceResponse, error) {
 	// 發送請求
-	respData, err := c.sendRequest("/B2CInvoice/Issue", req)
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/Issue", req)
 	if err != nil {
 		return nil, err
 	}
@@ -30,6 +63,9 @@ func (c *Client) IssueInvoice(req *IssueInvoiceRequest) (*IssueInvoiceResponse,
 	}
 	
 	// 檢查業務邏輯錯誤
+	if resp.RtnCode == rtnCodeDuplicated {
+		return nil, &Error{Code: ErrCodeAPI, Message: resp.RtnMsg, Err: errDuplicateRelateNumber}
+	}
 	if resp.RtnCode != 1 {
 		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
 	}
@@ -39,13 +75,23 @@ func (c *Client) IssueInvoice(req *IssueInvoiceRequest) (*IssueInvoiceResponse,
 
 // InvalidInvoice 作廢發票
 func (c *Client) InvalidInvoice(req *InvalidInvoiceRequest) (*InvalidInvoiceResponse, error) {
+	return c.InvalidInvoiceContext(context.Background(), req)
+}
+
+// InvalidInvoiceContext 作廢發票（可透過 ctx 取消或設定期限）
+func (c *Client) InvalidInvoiceContext(ctx context.Context, req *InvalidInvoiceRequest) (_ *InvalidInvoiceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "InvalidInvoice", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
 	// 驗證請求
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
 	
 	// 發送請求
-	respData, err := c.sendRequest("/B2CInvoice/Invalid", req)
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/Invalid", req)
 	if err != nil {
 		return nil, err
 	}
@@ -61,5 +107,368 @@ func (c *Client) InvalidInvoice(req *InvalidInvoiceRequest) (*InvalidInvoiceResp
 		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
 	}
 	
+	return &resp, nil
+}
+
+// DelayIssue 延遲開立發票
+func (c *Client) DelayIssue(req *DelayIssueRequest) (*DelayIssueResponse, error) {
+	return c.DelayIssueContext(context.Background(), req)
+}
+
+// DelayIssueContext 延遲開立發票（可透過 ctx 取消或設定期限）
+func (c *Client) DelayIssueContext(ctx context.Context, req *DelayIssueRequest) (_ *DelayIssueResponse, err error) {
+	ctx, span := c.startMethod(ctx, "DelayIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 向綠界驗證載具與捐贈碼
+	if err := c.verifyInvoiceCodes(ctx, &req.IssueInvoiceRequest); err != nil {
+		return nil, err
+	}
+	
+	// 設定商品序號
+	for i := range req.Items {
+		req.Items[i].ItemSeq = i + 1
+	}
+	
+	// 確認 Tsr 未曾使用；綠界明確拒絕時釋放，結果不明（例如網路錯誤）時保留
+	if err := c.reserveTsr(ctx, req.Tsr); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/DelayIssue", req)
+	if err != nil {
+		if IsError(err, ErrCodeAPI) {
+			c.tsrStore.Release(ctx, req.Tsr)
+		}
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp DelayIssueResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析延遲開立發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		c.tsrStore.Release(ctx, req.Tsr)
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// TriggerIssue 觸發開立發票
+func (c *Client) TriggerIssue(req *TriggerIssueRequest) (*TriggerIssueResponse, error) {
+	return c.TriggerIssueContext(context.Background(), req)
+}
+
+// TriggerIssueContext 觸發開立發票（可透過 ctx 取消或設定期限）
+func (c *Client) TriggerIssueContext(ctx context.Context, req *TriggerIssueRequest) (_ *TriggerIssueResponse, err error) {
+	ctx, span := c.startMethod(ctx, "TriggerIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/TriggerIssue", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp TriggerIssueResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析觸發開立發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// CancelDelayIssue 取消延遲開立發票
+func (c *Client) CancelDelayIssue(req *CancelDelayIssueRequest) (*CancelDelayIssueResponse, error) {
+	return c.CancelDelayIssueContext(context.Background(), req)
+}
+
+// CancelDelayIssueContext 取消延遲開立發票（可透過 ctx 取消或設定期限）
+func (c *Client) CancelDelayIssueContext(ctx context.Context, req *CancelDelayIssueRequest) (_ *CancelDelayIssueResponse, err error) {
+	ctx, span := c.startMethod(ctx, "CancelDelayIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/CancelDelayIssue", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp CancelDelayIssueResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析取消延遲開立發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// InvoiceNotify 發送發票通知
+func (c *Client) InvoiceNotify(req *InvoiceNotifyRequest) (*InvoiceNotifyResponse, error) {
+	return c.InvoiceNotifyContext(context.Background(), req)
+}
+
+// InvoiceNotifyContext 發送發票通知（可透過 ctx 取消或設定期限）
+func (c *Client) InvoiceNotifyContext(ctx context.Context, req *InvoiceNotifyRequest) (_ *InvoiceNotifyResponse, err error) {
+	ctx, span := c.startMethod(ctx, "InvoiceNotify", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/InvoiceNotify", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp InvoiceNotifyResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析發票通知回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// VoidWithReIssue 作廢重開發票
+func (c *Client) VoidWithReIssue(req *VoidWithReIssueRequest) (*VoidWithReIssueResponse, error) {
+	return c.VoidWithReIssueContext(context.Background(), req)
+}
+
+// VoidWithReIssueContext 作廢重開發票（可透過 ctx 取消或設定期限）
+func (c *Client) VoidWithReIssueContext(ctx context.Context, req *VoidWithReIssueRequest) (_ *VoidWithReIssueResponse, err error) {
+	ctx, span := c.startMethod(ctx, "VoidWithReIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	span.SetAttributes(attrInvoiceNo.String(req.Invalid.InvoiceNo), attrRelateNumber.String(req.Issue.RelateNumber))
+	
+	// 向綠界驗證載具與捐贈碼
+	if err := c.verifyInvoiceCodes(ctx, req.Issue); err != nil {
+		return nil, err
+	}
+	
+	// 設定商品序號
+	for i := range req.Issue.Items {
+		req.Issue.Items[i].ItemSeq = i + 1
+	}
+	
+	var apiReq voidWithReIssueRequest
+	apiReq.VoidModel.InvoiceNo = req.Invalid.InvoiceNo
+	apiReq.VoidModel.VoidReason = req.Invalid.Reason
+	apiReq.IssueModel.IssueInvoiceRequest = req.Issue
+	apiReq.IssueModel.InvoiceDate = req.Invalid.InvoiceDate
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/VoidWithReIssue", &apiReq)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp VoidWithReIssueResponse
+	if err := json.Unmarshal(respData, &resp.Invalid); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析作廢重開回應失敗: %v", err))
+	}
+	if err := json.Unmarshal(respData, &resp.Issue); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析作廢重開回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.Issue.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.Issue.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// verifyInvoiceCodes 開立前向綠界確認手機條碼與捐贈碼存在，未啟用驗證時不做任何事
+func (c *Client) verifyInvoiceCodes(ctx context.Context, req *IssueInvoiceRequest) error {
+	if !c.verifyCarrier {
+		return nil
+	}
+	
+	if req.CarrierType == CarrierTypeMobile {
+		exist, err := c.CheckBarcodeContext(ctx, req.CarrierNum)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return NewError(ErrCodeValidation, fmt.Sprintf("手機條碼不存在: %s", req.CarrierNum))
+		}
+	}
+	
+	if req.Donation == DonationYes {
+		exist, err := c.CheckLoveCodeContext(ctx, req.LoveCode)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return NewError(ErrCodeValidation, fmt.Sprintf("捐贈碼不存在: %s", req.LoveCode))
+		}
+	}
+	
+	return nil
+}
+
+// CheckBarcode 向綠界驗證手機條碼是否存在
+func (c *Client) CheckBarcode(barcode string) (bool, error) {
+	return c.CheckBarcodeContext(context.Background(), barcode)
+}
+
+// CheckBarcodeContext 向綠界驗證手機條碼是否存在（可透過 ctx 取消或設定期限）
+func (c *Client) CheckBarcodeContext(ctx context.Context, barcode string) (_ bool, err error) {
+	ctx, span := c.startMethod(ctx, "CheckBarcode", nil)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 格式錯誤的條碼不需送出
+	if !barcodeRegex.MatchString(barcode) {
+		return false, NewError(ErrCodeValidation, "手機條碼格式不正確")
+	}
+	
+	return c.checkCode(ctx, "/B2CInvoice/CheckBarcode", &checkBarcodeRequest{BarCode: barcode})
+}
+
+// CheckLoveCode 向綠界驗證捐贈碼是否存在
+func (c *Client) CheckLoveCode(loveCode string) (bool, error) {
+	return c.CheckLoveCodeContext(context.Background(), loveCode)
+}
+
+// CheckLoveCodeContext 向綠界驗證捐贈碼是否存在（可透過 ctx 取消或設定期限）
+func (c *Client) CheckLoveCodeContext(ctx context.Context, loveCode string) (_ bool, err error) {
+	ctx, span := c.startMethod(ctx, "CheckLoveCode", nil)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 格式錯誤的捐贈碼不需送出
+	if !ValidateLoveCode(loveCode) {
+		return false, NewError(ErrCodeValidation, "捐贈碼格式不正確")
+	}
+	
+	return c.checkCode(ctx, "/B2CInvoice/CheckLoveCode", &checkLoveCodeRequest{LoveCode: loveCode})
+}
+
+// checkCode 發送手機條碼/捐贈碼驗證請求
+func (c *Client) checkCode(ctx context.Context, apiPath string, req interface{}) (bool, error) {
+	// 發送請求
+	respData, err := c.sendRequest(ctx, apiPath, req)
+	if err != nil {
+		return false, err
+	}
+	
+	// 解析回應
+	var resp checkCodeResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return false, NewError(ErrCodeParse, fmt.Sprintf("解析驗證回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return false, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return resp.IsExist == "Y", nil
+}
+
+// OfflineIssue 上傳離線開立的發票
+func (c *Client) OfflineIssue(req *OfflineIssueRequest) (*IssueInvoiceResponse, error) {
+	return c.OfflineIssueContext(context.Background(), req)
+}
+
+// OfflineIssueContext 上傳離線開立的發票（可透過 ctx 取消或設定期限）
+func (c *Client) OfflineIssueContext(ctx context.Context, req *OfflineIssueRequest) (_ *IssueInvoiceResponse, err error) {
+	ctx, span := c.startMethod(ctx, "OfflineIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 向綠界驗證載具與捐贈碼
+	if err := c.verifyInvoiceCodes(ctx, &req.IssueInvoiceRequest); err != nil {
+		return nil, err
+	}
+	
+	// 設定商品序號
+	for i := range req.Items {
+		req.Items[i].ItemSeq = i + 1
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/OfflineIssue", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp IssueInvoiceResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析離線發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, &Error{Code: ErrCodeAPI, Message: resp.RtnMsg, Err: errOfflineRejected}
+	}
+	
 	return &resp, nil
 }
\ No newline at end of file
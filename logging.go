@@ -0,0 +1,206 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// redactedValue 遮蔽後的欄位值
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactFields 記錄日誌時預設遮蔽的欄位（請求與查詢回應中的個資）
+var DefaultRedactFields = []string{
+	"CustomerEmail",
+	"CustomerPhone",
+	"CustomerAddr",
+	"CarrierNum",
+	"NotifyMail",
+	"NotifyPhone",
+	"EmailAddress",
+	"TelephoneNumber",
+	"ContactName",
+	"Phone",
+	"Address",
+	"IIS_Customer_Email",
+	"IIS_Customer_Phone",
+	"IIS_Customer_Addr",
+	"IIS_Carrier_Num",
+	"HashKey",
+	"HashIV",
+}
+
+// WithLogger 設定結構化日誌
+// 每次 API 呼叫記錄 API 路徑、商店代號、RelateNumber、耗時、TransCode 與 RtnCode，
+// 成功為 Debug 等級、失敗為 Warn 等級；SetDebug(true) 時另記錄遮蔽個資後的請求與回應內容
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithRedactFields 設定記錄日誌時遮蔽的欄位，取代 DefaultRedactFields；適用於任何層級的同名欄位
+func WithRedactFields(fields ...string) Option {
+	return func(c *Client) error {
+		c.redact = redactSet(fields)
+		return nil
+	}
+}
+
+// WithDebugOutput 設定未透過 WithLogger 設定日誌時，SetDebug 自動建立的日誌輸出位置，預設為標準輸出
+func WithDebugOutput(w io.Writer) Option {
+	return func(c *Client) error {
+		if w == nil {
+			return NewError(ErrCodeValidation, "除錯輸出不能為 nil")
+		}
+		c.debugOutput = w
+		return nil
+	}
+}
+
+// LogValue 實作 slog.LogValuer，記錄客戶端時不輸出 HashKey 與 HashIV
+func (c *Client) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("merchant_id", c.MerchantID),
+		slog.String("env", string(c.Env)),
+		slog.String("hash_key", redactedValue),
+		slog.String("hash_iv", redactedValue),
+	)
+}
+
+// logRequest 記錄一次 API 呼叫
+func (c *Client) logRequest(ctx context.Context, apiPath string, data interface{}, respData []byte, transCode int, duration time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+	
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+	
+	attrs := []slog.Attr{
+		slog.String("api", apiPath),
+		slog.String("merchant_id", c.MerchantID),
+		slog.Duration("duration", duration),
+	}
+	
+	relateNumber, invoiceNo := requestIDs(data)
+	if relateNumber != "" {
+		attrs = append(attrs, slog.String("relate_number", relateNumber))
+	}
+	if invoiceNo != "" {
+		attrs = append(attrs, slog.String("invoice_no", invoiceNo))
+	}
+	
+	if transCode != 0 {
+		attrs = append(attrs, slog.Int("trans_code", transCode))
+	}
+	if respData != nil {
+		if code, ok := rtnCode(respData); ok {
+			attrs = append(attrs, slog.Int("rtn_code", code))
+		}
+	}
+	
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			attrs = append(attrs, slog.String("error_code", string(e.Code)))
+		}
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	
+	if c.debug {
+		reqData, _ := json.Marshal(data)
+		attrs = append(attrs, slog.String("request", c.redactJSON(reqData)))
+		if respData != nil {
+			attrs = append(attrs, slog.String("response", c.redactJSON(respData)))
+		}
+	}
+	
+	c.logger.LogAttrs(ctx, level, "ecpay: API 呼叫", attrs...)
+}
+
+// requestIDs 取得請求中的 RelateNumber 與 InvoiceNo
+// 直接讀取結構欄位，不重新序列化請求
+func requestIDs(data interface{}) (relateNumber, invoiceNo string) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return "", ""
+	}
+	return stringField(v, "RelateNumber"), stringField(v, "InvoiceNo")
+}
+
+// stringField 取得結構中的字串欄位，欄位不存在或位於 nil 內嵌指標時回傳空字串
+func stringField(v reflect.Value, name string) string {
+	field, ok := v.Type().FieldByName(name)
+	if !ok {
+		return ""
+	}
+	f, err := v.FieldByIndexErr(field.Index)
+	if err != nil || f.Kind() != reflect.String {
+		return ""
+	}
+	return f.String()
+}
+
+// rtnCode 取得解密後回應的 RtnCode
+func rtnCode(respData []byte) (int, bool) {
+	var rtn struct {
+		RtnCode flexNumber `json:"RtnCode"`
+	}
+	if err := json.Unmarshal(respData, &rtn); err != nil {
+		return 0, false
+	}
+	return rtn.RtnCode.Int(), true
+}
+
+// redactJSON 遮蔽 JSON 中的指定欄位
+func (c *Client) redactJSON(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return redactedValue
+	}
+	
+	redacted, err := json.Marshal(redactValue(v, c.redact))
+	if err != nil {
+		return redactedValue
+	}
+	return string(redacted)
+}
+
+// redactValue 遞迴遮蔽指定欄位，空值不遮蔽以便判斷是否有填寫
+func redactValue(v interface{}, fields map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if fields[k] && child != nil && child != "" {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = redactValue(child, fields)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child, fields)
+		}
+	}
+	return v
+}
+
+// redactSet 建立遮蔽欄位集合
+func redactSet(fields []string) map[string]bool {
+	set := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		set[field] = true
+	}
+	return set
+}
\ No newline at end of file
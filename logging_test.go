@@ -0,0 +1,59 @@
+package ecpay_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+)
+
+func TestLoggingRedactsB2BContact(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	_, client := newTestClient(t, ecpay.WithLogger(logger))
+	client.SetDebug(true)
+	
+	_, err := client.B2BMaintainCustomer(&ecpay.B2BMaintainCustomerRequest{
+		Action:          "Add",
+		Identifier:      "22099131",
+		ExchangeMode:    ecpay.B2BExchangeModeStorage,
+		TelephoneNumber: "0223456789",
+		ContactName:     "王小明",
+	})
+	if err != nil {
+		t.Fatalf("B2BMaintainCustomer: %v", err)
+	}
+	
+	out := buf.String()
+	if !strings.Contains(out, "22099131") || !strings.Contains(out, "[REDACTED]") {
+		t.Fatalf("log does not contain the redacted request:\n%s", out)
+	}
+	if strings.Contains(out, "0223456789") || strings.Contains(out, "王小明") {
+		t.Errorf("log contains contact details:\n%s", out)
+	}
+}
+
+func TestSetDebugOffRemovesDebugLogger(t *testing.T) {
+	var buf bytes.Buffer
+	_, client := newTestClient(t, ecpay.WithDebugOutput(&buf))
+	
+	// 除錯模式自動建立的日誌寫入除錯輸出，關閉後不再輸出
+	client.SetDebug(true)
+	if _, err := client.IssueInvoice(testIssueRequest("R001")); err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	if !strings.Contains(buf.String(), "R001") {
+		t.Fatalf("debug output does not contain the request:\n%s", buf.String())
+	}
+	
+	buf.Reset()
+	client.SetDebug(false)
+	if _, err := client.IssueInvoice(testIssueRequest("R002")); err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("debug output after SetDebug(false):\n%s", buf.String())
+	}
+}
\ No newline at end of file
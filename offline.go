@@ -0,0 +1,556 @@
+package ecpay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 離線開立
+//
+// POS 在連線時以 Reserve 從本期財政部配號中取出尚未設定的區間，向綠界新增為離線專用字軌，
+// 並為每個號碼產生隨機碼；斷線期間以 Issue 在本機配號開立，恢復連線後以 Upload 依配號順序上傳至綠界。
+// 號碼區段、配號游標、待上傳與已上傳的發票皆保存在 OfflineStore，每次異動後立即寫入，
+// 程式重啟後不會重複使用號碼，也不會重複開立同一筆訂單。
+
+// NumberBlock 預先保留的發票號碼區段，對應綠界上的一個離線專用字軌
+type NumberBlock struct {
+	TrackID       string // 綠界字軌 ID
+	Period        InvoicePeriod
+	InvType       string
+	InvoiceHeader string
+	Start         int      // 起始號碼
+	End           int      // 結束號碼（含）
+	Next          int      // 下一個可用號碼
+	RandomNumbers []string // 與號碼一一對應的隨機碼，索引為 號碼 - Start
+}
+
+// Remaining 剩餘可用張數
+func (b *NumberBlock) Remaining() int {
+	return b.End - b.Next + 1
+}
+
+// OfflineInvoice 離線開立的發票
+type OfflineInvoice struct {
+	Seq          int64 // 配號順序
+	InvoiceNo    string
+	InvoiceDate  time.Time
+	RandomNumber string
+	Request      IssueInvoiceRequest
+	Attempts     int    // 上傳嘗試次數
+	LastError    string // 最近一次上傳失敗的原因
+}
+
+// OfflineState 離線開立狀態
+type OfflineState struct {
+	Blocks   []NumberBlock
+	Pending  []OfflineInvoice  // 待上傳發票，依 Seq 排序
+	Failed   []OfflineInvoice  // 遭綠界拒絕而無法上傳的發票，需人工處理
+	Uploaded map[string]string // 已上傳的 RelateNumber → InvoiceNo
+	NextSeq  int64
+}
+
+// OfflineStore 離線開立狀態儲存
+type OfflineStore interface {
+	// Load 讀取狀態，尚無資料時回傳空的狀態
+	Load() (*OfflineState, error)
+	// Save 寫入狀態，必須在回傳前確實保存
+	Save(state *OfflineState) error
+}
+
+// FileOfflineStore 以 JSON 檔案保存離線開立狀態
+type FileOfflineStore struct {
+	path string
+}
+
+// NewFileOfflineStore 建立檔案儲存
+func NewFileOfflineStore(path string) *FileOfflineStore {
+	return &FileOfflineStore{path: path}
+}
+
+// Load 讀取狀態
+func (s *FileOfflineStore) Load() (*OfflineState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &OfflineState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("讀取離線狀態失敗: %v", err)
+	}
+	
+	var state OfflineState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析離線狀態失敗: %v", err)
+	}
+	return &state, nil
+}
+
+// Save 寫入狀態，先寫入暫存檔再置換以避免寫入中斷造成檔案損毀
+func (s *FileOfflineStore) Save(state *OfflineState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("編碼離線狀態失敗: %v", err)
+	}
+	
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("建立暫存檔失敗: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("寫入離線狀態失敗: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("寫入離線狀態失敗: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("寫入離線狀態失敗: %v", err)
+	}
+	
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("置換離線狀態檔失敗: %v", err)
+	}
+	return nil
+}
+
+// OfflineIssuer 離線開立發票
+type OfflineIssuer struct {
+	client *Client
+	store  OfflineStore
+	now    func() time.Time
+	
+	reserveMu sync.Mutex // 避免同時新增字軌選到相同區間
+	uploadMu  sync.Mutex // 避免同時上傳造成重複送出
+	
+	mu    sync.Mutex // 保護 state，不在網路請求期間持有
+	state *OfflineState
+}
+
+// NewOfflineIssuer 建立離線開立器並讀取已保存的狀態
+func NewOfflineIssuer(client *Client, store OfflineStore) (*OfflineIssuer, error) {
+	state, err := store.Load()
+	if err != nil {
+		return nil, NewError(ErrCodeRequest, err.Error())
+	}
+	if state.Uploaded == nil {
+		state.Uploaded = make(map[string]string)
+	}
+	
+	return &OfflineIssuer{
+		client: client,
+		store:  store,
+		now:    time.Now,
+		state:  state,
+	}, nil
+}
+
+// Reserve 保留 count 張離線發票號碼（須為 50 的倍數）
+// 號碼取自本期財政部配號中尚未設定為字軌的區間，並向綠界新增為字軌；
+// 新增的字軌維持未啟用，線上開立不會使用，因此不會與線上開立撞號
+func (o *OfflineIssuer) Reserve(ctx context.Context, invType string, count int) (_ *NumberBlock, err error) {
+	ctx, span := o.client.startMethod(ctx, "OfflineIssuer.Reserve", nil)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	if invType != InvTypeGeneral && invType != InvTypeSpecial {
+		return nil, NewError(ErrCodeValidation, "InvType 不正確")
+	}
+	
+	if count <= 0 || count%50 != 0 {
+		return nil, NewError(ErrCodeValidation, "保留張數必須為 50 的倍數")
+	}
+	
+	o.reserveMu.Lock()
+	defer o.reserveMu.Unlock()
+	
+	// 從財政部配號中找出未設定字軌的區間
+	period := PeriodOf(o.now())
+	header, start, err := o.findFreeRange(ctx, period, invType, count)
+	if err != nil {
+		return nil, err
+	}
+	end := start + count - 1
+	
+	// 向綠界新增離線專用字軌
+	word, err := o.client.AddInvoiceWordSettingContext(ctx, &AddInvoiceWordRequest{
+		Period:        period,
+		InvType:       invType,
+		InvoiceHeader: header,
+		InvoiceStart:  fmt.Sprintf("%08d", start),
+		InvoiceEnd:    fmt.Sprintf("%08d", end),
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	randomNumbers := make([]string, count)
+	for i := range randomNumbers {
+		if randomNumbers[i], err = newRandomNumber(); err != nil {
+			return nil, NewError(ErrCodeRequest, fmt.Sprintf("產生隨機碼失敗: %v", err))
+		}
+	}
+	
+	block := NumberBlock{
+		TrackID:       word.TrackID,
+		Period:        period,
+		InvType:       invType,
+		InvoiceHeader: header,
+		Start:         start,
+		End:           end,
+		Next:          start,
+		RandomNumbers: randomNumbers,
+	}
+	
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	o.state.Blocks = append(o.state.Blocks, block)
+	if err := o.store.Save(o.state); err != nil {
+		o.state.Blocks = o.state.Blocks[:len(o.state.Blocks)-1]
+		return nil, NewError(ErrCodeRequest, fmt.Sprintf("字軌 %s 已新增但保存失敗: %v", word.TrackID, err))
+	}
+	
+	return &block, nil
+}
+
+// findFreeRange 在財政部配號中找出連續 count 張尚未設定為字軌的號碼，回傳字軌英文與起始號碼
+func (o *OfflineIssuer) findFreeRange(ctx context.Context, period InvoicePeriod, invType string, count int) (string, int, error) {
+	gov, err := o.client.GetGovInvoiceWordSettingContext(ctx, &GetGovInvoiceWordSettingRequest{Year: period.Year})
+	if err != nil {
+		return "", 0, err
+	}
+	
+	words, err := o.client.GetInvoiceWordSettingContext(ctx, &InvoiceWordFilter{Period: period})
+	if err != nil {
+		return "", 0, err
+	}
+	
+	for _, g := range gov.InvoiceInfo {
+		if g.Period != period || g.InvType != invType {
+			continue
+		}
+		
+		govStart, err := parseInvoiceNumber(g.InvoiceStart)
+		if err != nil {
+			return "", 0, err
+		}
+		govEnd, err := parseInvoiceNumber(g.InvoiceEnd)
+		if err != nil {
+			return "", 0, err
+		}
+		
+		// 同字軌英文已設定的區間，依起始號碼排序
+		var used [][2]int
+		for _, w := range words {
+			if w.InvoiceHeader != g.InvoiceHeader {
+				continue
+			}
+			start, err := parseInvoiceNumber(w.InvoiceStart)
+			if err != nil {
+				return "", 0, err
+			}
+			end, err := parseInvoiceNumber(w.InvoiceEnd)
+			if err != nil {
+				return "", 0, err
+			}
+			used = append(used, [2]int{start, end})
+		}
+		sort.Slice(used, func(i, j int) bool {
+			return used[i][0] < used[j][0]
+		})
+		
+		next := govStart
+		for _, u := range used {
+			if u[0] > next && u[0]-next >= count {
+				break
+			}
+			if u[1] >= next {
+				next = u[1] + 1
+			}
+		}
+		if next+count-1 <= govEnd {
+			return g.InvoiceHeader, next, nil
+		}
+	}
+	
+	return "", 0, NewError(ErrCodeNotFound, fmt.Sprintf("本期財政部配號已無 %d 張未使用的號碼", count))
+}
+
+// Remaining 本期剩餘可離線開立的張數
+func (o *OfflineIssuer) Remaining(invType string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	period := PeriodOf(o.now())
+	remaining := 0
+	for i := range o.state.Blocks {
+		b := &o.state.Blocks[i]
+		if b.Period == period && b.InvType == invType {
+			remaining += b.Remaining()
+		}
+	}
+	return remaining
+}
+
+// Issue 離線開立發票，不需連線
+// 配號結果在回傳前即已保存，稍後以 Upload 上傳
+func (o *OfflineIssuer) Issue(req *IssueInvoiceRequest) (*OfflineInvoice, error) {
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	// 防止同一筆訂單重複開立
+	if o.issued(req.RelateNumber) {
+		return nil, NewError(ErrCodeValidation, fmt.Sprintf("RelateNumber 已離線開立: %s", req.RelateNumber))
+	}
+	
+	now := o.now()
+	period := PeriodOf(now)
+	
+	var block *NumberBlock
+	for i := range o.state.Blocks {
+		b := &o.state.Blocks[i]
+		if b.Period == period && b.InvType == req.InvType && b.Remaining() > 0 {
+			block = b
+			break
+		}
+	}
+	if block == nil {
+		return nil, NewError(ErrCodeValidation, "本期已無可用的離線發票號碼")
+	}
+	
+	number := block.Next
+	inv := OfflineInvoice{
+		Seq:          o.state.NextSeq,
+		InvoiceNo:    fmt.Sprintf("%s%08d", block.InvoiceHeader, number),
+		InvoiceDate:  now,
+		RandomNumber: block.RandomNumbers[number-block.Start],
+		Request:      *req,
+	}
+	inv.Request.Items = append([]Item(nil), req.Items...)
+	
+	block.Next++
+	o.state.NextSeq++
+	o.state.Pending = append(o.state.Pending, inv)
+	if err := o.store.Save(o.state); err != nil {
+		// 保存失敗時還原，號碼不會被使用
+		block.Next--
+		o.state.NextSeq--
+		o.state.Pending = o.state.Pending[:len(o.state.Pending)-1]
+		return nil, NewError(ErrCodeRequest, err.Error())
+	}
+	
+	o.pruneBlocks()
+	return &inv, nil
+}
+
+// Pending 待上傳的發票
+func (o *OfflineIssuer) Pending() []OfflineInvoice {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	return append([]OfflineInvoice(nil), o.state.Pending...)
+}
+
+// Failed 遭綠界拒絕而無法上傳的發票
+func (o *OfflineIssuer) Failed() []OfflineInvoice {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	return append([]OfflineInvoice(nil), o.state.Failed...)
+}
+
+// issued RelateNumber 是否已離線開立，呼叫端需持有 o.mu
+func (o *OfflineIssuer) issued(relateNumber string) bool {
+	if _, ok := o.state.Uploaded[relateNumber]; ok {
+		return true
+	}
+	for _, list := range [][]OfflineInvoice{o.state.Pending, o.state.Failed} {
+		for i := range list {
+			if list[i].Request.RelateNumber == relateNumber {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upload 依配號順序上傳待上傳的發票，回傳成功上傳的張數
+// 網路等無法確認結果的錯誤會停止上傳以維持順序，下次上傳前會先以 RelateNumber 查詢，
+// 確認綠界未收到才重送；遭綠界拒絕的發票移至 Failed 後繼續上傳下一張。
+// 上傳期間不鎖定狀態，Issue 可同時進行
+func (o *OfflineIssuer) Upload(ctx context.Context) (_ int, err error) {
+	ctx, span := o.client.startMethod(ctx, "OfflineIssuer.Upload", nil)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	o.uploadMu.Lock()
+	defer o.uploadMu.Unlock()
+	
+	o.mu.Lock()
+	pending := append([]OfflineInvoice(nil), o.state.Pending...)
+	o.mu.Unlock()
+	
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Seq < pending[j].Seq
+	})
+	
+	uploaded := 0
+	for i := range pending {
+		inv := pending[i]
+		
+		done, err := o.uploadOne(ctx, &inv)
+		switch {
+		case done:
+			if err := o.settle(inv, uploadDone); err != nil {
+				return uploaded, err
+			}
+			uploaded++
+			
+		case permanentUploadError(err):
+			inv.LastError = err.Error()
+			if err := o.settle(inv, uploadFailed); err != nil {
+				return uploaded, err
+			}
+			
+		default:
+			// 保存嘗試次數，下次上傳前會先查詢
+			inv.LastError = err.Error()
+			if saveErr := o.settle(inv, uploadRetry); saveErr != nil {
+				return uploaded, saveErr
+			}
+			return uploaded, err
+		}
+	}
+	
+	return uploaded, nil
+}
+
+// uploadResult 單張發票的上傳結果
+type uploadResult int
+
+const (
+	uploadDone   uploadResult = iota // 綠界已收到
+	uploadFailed                     // 綠界拒絕，不再重送
+	uploadRetry                      // 結果不明，下次再試
+)
+
+// settle 將單張發票的上傳結果寫回狀態並保存
+func (o *OfflineIssuer) settle(inv OfflineInvoice, result uploadResult) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	
+	idx := -1
+	for i := range o.state.Pending {
+		if o.state.Pending[i].Seq == inv.Seq {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	
+	switch result {
+	case uploadDone:
+		o.state.Pending = append(o.state.Pending[:idx], o.state.Pending[idx+1:]...)
+		o.state.Uploaded[inv.Request.RelateNumber] = inv.InvoiceNo
+	case uploadFailed:
+		o.state.Pending = append(o.state.Pending[:idx], o.state.Pending[idx+1:]...)
+		o.state.Failed = append(o.state.Failed, inv)
+	default:
+		o.state.Pending[idx] = inv
+	}
+	
+	if err := o.store.Save(o.state); err != nil {
+		return NewError(ErrCodeRequest, err.Error())
+	}
+	return nil
+}
+
+// errOfflineRejected 綠界以 RtnCode 拒絕離線發票
+var errOfflineRejected = errors.New("綠界拒絕離線發票")
+
+// permanentUploadError 綠界已明確拒絕的錯誤，重送也不會成功
+// 封包（TransCode）、連線與回應錯誤無法確認綠界是否收到，仍留待下次上傳
+func permanentUploadError(err error) bool {
+	return IsError(err, ErrCodeValidation) || errors.Is(err, errOfflineRejected)
+}
+
+// uploadOne 上傳單張發票，回傳是否已確認綠界收到
+func (o *OfflineIssuer) uploadOne(ctx context.Context, inv *OfflineInvoice) (bool, error) {
+	if inv.Attempts > 0 {
+		existing, err := o.client.GetIssueContext(ctx, &GetIssueRequest{RelateNumber: inv.Request.RelateNumber})
+		switch {
+		case err == nil && existing.InvoiceNo == inv.InvoiceNo:
+			return true, nil
+		case err == nil:
+			return false, NewError(ErrCodeValidation,
+				fmt.Sprintf("RelateNumber %s 已開立其他發票 %s", inv.Request.RelateNumber, existing.InvoiceNo))
+		case !IsError(err, ErrCodeNotFound):
+			// 無法確認狀態時不重送，避免重複開立
+			return false, err
+		}
+	}
+	
+	req := OfflineIssueRequest{
+		IssueInvoiceRequest: inv.Request,
+		InvoiceNo:           inv.InvoiceNo,
+		InvoiceDate:         inv.InvoiceDate.Format("2006-01-02 15:04:05"),
+		RandomNumber:        inv.RandomNumber,
+	}
+	
+	inv.Attempts++
+	if _, err := o.client.OfflineIssueContext(ctx, &req); err != nil {
+		return false, err
+	}
+	
+	return true, nil
+}
+
+// pruneBlocks 移除已用完或過期的號碼區段
+func (o *OfflineIssuer) pruneBlocks() {
+	period := PeriodOf(o.now())
+	blocks := o.state.Blocks[:0]
+	for _, b := range o.state.Blocks {
+		if b.Remaining() > 0 && b.Period == period {
+			blocks = append(blocks, b)
+		}
+	}
+	o.state.Blocks = blocks
+}
+
+// parseInvoiceNumber 解析 8 碼發票號碼
+func parseInvoiceNumber(s string) (int, error) {
+	if !invoiceNumberRegex.MatchString(s) {
+		return 0, NewError(ErrCodeParse, fmt.Sprintf("發票號碼格式錯誤: %s", s))
+	}
+	return strconv.Atoi(s)
+}
+
+// newRandomNumber 產生 4 碼隨機碼
+func newRandomNumber() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
\ No newline at end of file
@@ -0,0 +1,277 @@
+package ecpay_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+// newOfflineServer 建立本期配號 ZZ20000000~ZZ20000099 的模擬伺服器與客戶端
+func newOfflineServer(t *testing.T) (*ecpaytest.Server, *ecpay.Client) {
+	t.Helper()
+	
+	server, client := newTestClient(t)
+	server.AddGovInvoiceWord(ecpay.GovInvoiceWord{
+		Period:        ecpay.PeriodOf(time.Now()),
+		InvType:       ecpay.InvTypeGeneral,
+		InvoiceHeader: "ZZ",
+		InvoiceStart:  "20000000",
+		InvoiceEnd:    "20000099",
+		Number:        2,
+	})
+	return server, client
+}
+
+// openOfflineIssuer 以指定的狀態檔建立離線開立器
+func openOfflineIssuer(t *testing.T, client *ecpay.Client, path string) *ecpay.OfflineIssuer {
+	t.Helper()
+	
+	issuer, err := ecpay.NewOfflineIssuer(client, ecpay.NewFileOfflineStore(path))
+	if err != nil {
+		t.Fatalf("NewOfflineIssuer: %v", err)
+	}
+	return issuer
+}
+
+// testIssueRequest 建立測試用的發票請求
+func testIssueRequest(relateNumber string) *ecpay.IssueInvoiceRequest {
+	return &ecpay.IssueInvoiceRequest{
+		RelateNumber:  relateNumber,
+		CustomerName:  "測試客戶",
+		CustomerEmail: "test@example.com",
+		Print:         ecpay.PrintNo,
+		Donation:      ecpay.DonationNo,
+		TaxType:       ecpay.TaxTypeRegular,
+		SalesAmount:   "100",
+		InvType:       ecpay.InvTypeGeneral,
+		Vat:           ecpay.VatYes,
+		Items: []ecpay.Item{
+			{ItemName: "商品", ItemCount: 1, ItemWord: "個", ItemPrice: 100, ItemTaxType: ecpay.TaxTypeRegular, ItemAmount: 100},
+		},
+	}
+}
+
+func TestOfflineReserveAndExhaustion(t *testing.T) {
+	server, client := newOfflineServer(t)
+	issuer := openOfflineIssuer(t, client, filepath.Join(t.TempDir(), "offline.json"))
+	ctx := context.Background()
+	
+	block, err := issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if block.Start != 20000000 || block.End != 20000049 {
+		t.Fatalf("block = %d~%d, want 20000000~20000049", block.Start, block.End)
+	}
+	
+	track, ok := server.Track(block.TrackID)
+	if !ok {
+		t.Fatalf("track %s not added at ECPay", block.TrackID)
+	}
+	if track.UseStatus != ecpay.WordUseStatusUnused {
+		t.Errorf("track UseStatus = %d, want unused", track.UseStatus)
+	}
+	
+	for i := 0; i < 50; i++ {
+		inv, err := issuer.Issue(testIssueRequest(fmt.Sprintf("R%03d", i)))
+		if err != nil {
+			t.Fatalf("Issue #%d: %v", i, err)
+		}
+		if want := fmt.Sprintf("ZZ%08d", 20000000+i); inv.InvoiceNo != want {
+			t.Fatalf("Issue #%d InvoiceNo = %s, want %s", i, inv.InvoiceNo, want)
+		}
+	}
+	
+	if _, err := issuer.Issue(testIssueRequest("R050")); !ecpay.IsError(err, ecpay.ErrCodeValidation) {
+		t.Fatalf("Issue after exhaustion err = %v, want validation error", err)
+	}
+	if n := issuer.Remaining(ecpay.InvTypeGeneral); n != 0 {
+		t.Errorf("Remaining = %d, want 0", n)
+	}
+	
+	// 第二段取用配號中尚未設定的區間，配號用完後無法再保留
+	block, err = issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50)
+	if err != nil {
+		t.Fatalf("second Reserve: %v", err)
+	}
+	if block.Start != 20000050 {
+		t.Errorf("second block starts at %d, want 20000050", block.Start)
+	}
+	
+	if _, err := issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50); !ecpay.IsError(err, ecpay.ErrCodeNotFound) {
+		t.Errorf("Reserve beyond allocation err = %v, want not found", err)
+	}
+}
+
+func TestOfflinePersistence(t *testing.T) {
+	_, client := newOfflineServer(t)
+	path := filepath.Join(t.TempDir(), "offline.json")
+	ctx := context.Background()
+	
+	issuer := openOfflineIssuer(t, client, path)
+	if _, err := issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	first, err := issuer.Issue(testIssueRequest("R001"))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	
+	// 重新讀取狀態後不可重複使用號碼或訂單編號
+	reopened := openOfflineIssuer(t, client, path)
+	pending := reopened.Pending()
+	if len(pending) != 1 || pending[0].InvoiceNo != first.InvoiceNo || pending[0].RandomNumber != first.RandomNumber {
+		t.Fatalf("reloaded Pending = %+v, want %s", pending, first.InvoiceNo)
+	}
+	if n := reopened.Remaining(ecpay.InvTypeGeneral); n != 49 {
+		t.Errorf("reloaded Remaining = %d, want 49", n)
+	}
+	
+	second, err := reopened.Issue(testIssueRequest("R002"))
+	if err != nil {
+		t.Fatalf("Issue after reload: %v", err)
+	}
+	if second.InvoiceNo != "ZZ20000001" {
+		t.Errorf("InvoiceNo after reload = %s, want ZZ20000001", second.InvoiceNo)
+	}
+	
+	if _, err := reopened.Issue(testIssueRequest("R001")); !ecpay.IsError(err, ecpay.ErrCodeValidation) {
+		t.Errorf("duplicate pending RelateNumber err = %v, want validation error", err)
+	}
+	
+	// 已上傳的訂單編號同樣不可再開立
+	if n, err := reopened.Upload(ctx); err != nil || n != 2 {
+		t.Fatalf("Upload = %d, %v, want 2", n, err)
+	}
+	uploaded := openOfflineIssuer(t, client, path)
+	if _, err := uploaded.Issue(testIssueRequest("R001")); !ecpay.IsError(err, ecpay.ErrCodeValidation) {
+		t.Errorf("duplicate uploaded RelateNumber err = %v, want validation error", err)
+	}
+}
+
+func TestOfflineUploadOrder(t *testing.T) {
+	server, client := newOfflineServer(t)
+	issuer := openOfflineIssuer(t, client, filepath.Join(t.TempDir(), "offline.json"))
+	ctx := context.Background()
+	
+	if _, err := issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	var issued []*ecpay.OfflineInvoice
+	for _, relateNumber := range []string{"R001", "R002", "R003"} {
+		inv, err := issuer.Issue(testIssueRequest(relateNumber))
+		if err != nil {
+			t.Fatalf("Issue %s: %v", relateNumber, err)
+		}
+		issued = append(issued, inv)
+	}
+	
+	// 第二張已開立但回應中斷，上傳停在第二張
+	server.InjectFault("/B2CInvoice/OfflineIssue", 2, ecpaytest.Fault{Kind: ecpaytest.FaultTruncate})
+	n, err := issuer.Upload(ctx)
+	if n != 1 || err == nil {
+		t.Fatalf("Upload = %d, %v, want 1 and an error", n, err)
+	}
+	
+	pending := issuer.Pending()
+	if len(pending) != 2 || pending[0].InvoiceNo != issued[1].InvoiceNo || pending[1].InvoiceNo != issued[2].InvoiceNo {
+		t.Fatalf("Pending after partial upload = %+v", pending)
+	}
+	if pending[0].Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", pending[0].Attempts)
+	}
+	
+	// 恢復後先查詢第二張，確認已收到不重送，再上傳第三張
+	server.ClearFaults()
+	if n, err := issuer.Upload(ctx); n != 2 || err != nil {
+		t.Fatalf("second Upload = %d, %v, want 2", n, err)
+	}
+	if calls := server.Calls("/B2CInvoice/OfflineIssue"); calls != 3 {
+		t.Errorf("OfflineIssue calls = %d, want 3", calls)
+	}
+	if calls := server.Calls("/B2CInvoice/GetIssue"); calls != 1 {
+		t.Errorf("GetIssue calls = %d, want 1", calls)
+	}
+	
+	for _, inv := range issued {
+		got, ok := server.InvoiceByRelateNumber(inv.Request.RelateNumber)
+		if !ok || got.InvoiceNo != inv.InvoiceNo || got.RandomNumber != inv.RandomNumber {
+			t.Errorf("server invoice for %s = %+v, want %s", inv.Request.RelateNumber, got, inv.InvoiceNo)
+		}
+	}
+}
+
+func TestOfflineUploadRejected(t *testing.T) {
+	server, client := newOfflineServer(t)
+	issuer := openOfflineIssuer(t, client, filepath.Join(t.TempDir(), "offline.json"))
+	ctx := context.Background()
+	
+	if _, err := issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	for _, relateNumber := range []string{"R001", "R002"} {
+		if _, err := issuer.Issue(testIssueRequest(relateNumber)); err != nil {
+			t.Fatalf("Issue %s: %v", relateNumber, err)
+		}
+	}
+	
+	// 第一張遭綠界拒絕，不應卡住後面的發票
+	server.InjectFault("/B2CInvoice/OfflineIssue", 1, ecpaytest.Fault{
+		Kind: ecpaytest.FaultRtnCode,
+		Code: ecpaytest.RtnCodeFailed,
+		Msg:  "資料錯誤",
+	})
+	if n, err := issuer.Upload(ctx); n != 1 || err != nil {
+		t.Fatalf("Upload = %d, %v, want 1", n, err)
+	}
+	
+	failed := issuer.Failed()
+	if len(failed) != 1 || failed[0].Request.RelateNumber != "R001" || failed[0].LastError == "" {
+		t.Fatalf("Failed = %+v, want R001 with its error", failed)
+	}
+	if pending := issuer.Pending(); len(pending) != 0 {
+		t.Errorf("Pending = %+v, want empty", pending)
+	}
+	if _, ok := server.InvoiceByRelateNumber("R002"); !ok {
+		t.Error("R002 was not uploaded")
+	}
+}
+
+func TestOfflineUploadTransCodeError(t *testing.T) {
+	server, client := newOfflineServer(t)
+	issuer := openOfflineIssuer(t, client, filepath.Join(t.TempDir(), "offline.json"))
+	ctx := context.Background()
+	
+	if _, err := issuer.Reserve(ctx, ecpay.InvTypeGeneral, 50); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if _, err := issuer.Issue(testIssueRequest("R001")); err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	
+	// 封包錯誤不是綠界對發票的拒絕，發票仍待上傳
+	server.InjectFault("/B2CInvoice/OfflineIssue", 1, ecpaytest.Fault{
+		Kind: ecpaytest.FaultTransCode,
+		Code: 999,
+		Msg:  "系統忙碌",
+	})
+	if n, err := issuer.Upload(ctx); n != 0 || !ecpay.IsError(err, ecpay.ErrCodeAPI) {
+		t.Fatalf("Upload = %d, %v, want 0 and an API error", n, err)
+	}
+	if failed := issuer.Failed(); len(failed) != 0 {
+		t.Fatalf("Failed = %+v, want empty", failed)
+	}
+	if pending := issuer.Pending(); len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("Pending = %+v, want R001 with one attempt", pending)
+	}
+	
+	server.ClearFaults()
+	if n, err := issuer.Upload(ctx); n != 1 || err != nil {
+		t.Fatalf("second Upload = %d, %v, want 1", n, err)
+	}
+}
\ No newline at end of file
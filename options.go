@@ -0,0 +1,121 @@
+package ecpay
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultRevision 預設的 API 版本
+const DefaultRevision = "3.0.0"
+
+// Option 客戶端設定選項
+type Option func(*Client) error
+
+// WithHTTPClient 使用自訂的 http.Client，例如設定 Proxy、TLS 或連線池
+// 客戶端會保存一份複本，之後的 SetTimeout / SetTransport 不會影響傳入的 http.Client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) error {
+		if httpClient == nil {
+			return NewError(ErrCodeValidation, "http.Client 不能為 nil")
+		}
+		copied := *httpClient
+		c.httpClient = &copied
+		return nil
+	}
+}
+
+// WithTransport 設定 HTTP 傳輸層，例如加上監控或錄製用的 RoundTripper
+// 與 WithHTTPClient 併用時不論先後順序，都會取代該 http.Client 的 Transport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) error {
+		if transport == nil {
+			return NewError(ErrCodeValidation, "Transport 不能為 nil")
+		}
+		c.transport = transport
+		return nil
+	}
+}
+
+// WithTimeout 設定逾時時間
+// 與 WithHTTPClient 併用時不論先後順序，都會取代該 http.Client 的 Timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return NewError(ErrCodeValidation, "逾時時間不能小於 0")
+		}
+		c.timeout = &timeout
+		return nil
+	}
+}
+
+// WithBaseURL 以指定網址取代 env，例如透過內部閘道或模擬伺服器連線
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		c.Env = Environment(strings.TrimSuffix(baseURL, "/"))
+		return nil
+	}
+}
+
+// WithVerifyCarrier 開立發票（含延遲開立、作廢重開與離線上傳）前向綠界驗證手機條碼與捐贈碼是否存在
+func WithVerifyCarrier(verify bool) Option {
+	return func(c *Client) error {
+		c.verifyCarrier = verify
+		return nil
+	}
+}
+
+// WithTsrStore 設定延遲開立檢查 Tsr 不可重複所用的儲存
+// 預設為 NewMemoryTsrStore，只在單一程序內有效；多台主機共用商店代號時應改用共用儲存
+func WithTsrStore(store TsrStore) Option {
+	return func(c *Client) error {
+		if store == nil {
+			return NewError(ErrCodeValidation, "TsrStore 不能為 nil")
+		}
+		c.tsrStore = store
+		return nil
+	}
+}
+
+// WithUserAgent 設定請求的 User-Agent 標頭
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) error {
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithRevision 設定請求 RqHeader 的 API 版本，預設為 DefaultRevision
+func WithRevision(revision string) Option {
+	return func(c *Client) error {
+		if revision == "" {
+			return NewError(ErrCodeValidation, "Revision 不能為空")
+		}
+		c.revision = revision
+		return nil
+	}
+}
+
+// validate 驗證客戶端設定
+func (c *Client) validate() error {
+	if c.MerchantID == "" {
+		return NewError(ErrCodeValidation, "MerchantID 不能為空")
+	}
+	
+	// AES-128-CBC 的金鑰與 IV 皆為 16 碼
+	if len(c.HashKey) != 16 {
+		return NewError(ErrCodeValidation, fmt.Sprintf("HashKey 長度必須為 16，目前為 %d", len(c.HashKey)))
+	}
+	if len(c.HashIV) != 16 {
+		return NewError(ErrCodeValidation, fmt.Sprintf("HashIV 長度必須為 16，目前為 %d", len(c.HashIV)))
+	}
+	
+	u, err := url.Parse(string(c.Env))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewError(ErrCodeValidation, fmt.Sprintf("環境網址不正確: %q", c.Env))
+	}
+	
+	return nil
+}
\ No newline at end of file
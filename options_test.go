@@ -0,0 +1,61 @@
+package ecpay_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+// countingTransport 計算經過的請求數
+type countingTransport struct {
+	calls atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient 建立模擬伺服器與連線至該伺服器的客戶端，測試結束時關閉伺服器
+func newTestClient(t *testing.T, opts ...ecpay.Option) (*ecpaytest.Server, *ecpay.Client) {
+	t.Helper()
+	
+	server := ecpaytest.NewServer()
+	t.Cleanup(server.Close)
+	
+	client, err := server.NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return server, client
+}
+
+func TestOptionOrder(t *testing.T) {
+	// WithTransport、WithTimeout 在 WithHTTPClient 之前仍然生效
+	transport := &countingTransport{}
+	server, client := newTestClient(t,
+		ecpay.WithTransport(transport),
+		ecpay.WithTimeout(200*time.Millisecond),
+		ecpay.WithHTTPClient(&http.Client{}),
+	)
+	
+	if _, err := client.IssueInvoice(testIssueRequest("R001")); err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	if n := transport.calls.Load(); n != 1 {
+		t.Errorf("transport calls = %d, want 1", n)
+	}
+	
+	server.InjectFault("/B2CInvoice/Issue", 0, ecpaytest.Fault{Kind: ecpaytest.FaultDelay, Delay: 5 * time.Second})
+	start := time.Now()
+	if _, err := client.IssueInvoice(testIssueRequest("R002")); !ecpay.IsError(err, ecpay.ErrCodeNetwork) {
+		t.Fatalf("IssueInvoice err = %v, want network error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v, want the 200ms timeout", elapsed)
+	}
+}
\ No newline at end of file
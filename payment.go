@@ -0,0 +1,253 @@
+package ecpay
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentNotification 綠界全方位金流 (AIO) 付款結果通知
+type PaymentNotification struct {
+	MerchantID           string
+	MerchantTradeNo      string // 特店交易編號
+	StoreID              string
+	RtnCode              int // 1 為付款成功
+	RtnMsg               string
+	TradeNo              string // 綠界交易編號
+	TradeAmt             int
+	PaymentDate          time.Time
+	PaymentType          string
+	PaymentTypeChargeFee int
+	TradeDate            time.Time
+	SimulatePaid         bool // 是否為模擬付款
+	CustomField1         string
+	CustomField2         string
+	CustomField3         string
+	CustomField4         string
+	
+	// Values 原始通知欄位
+	Values url.Values
+}
+
+// Paid 是否為實際付款成功（排除模擬付款）
+func (n *PaymentNotification) Paid() bool {
+	return n.RtnCode == 1 && !n.SimulatePaid
+}
+
+// InvoiceMapper 將付款通知轉換為開立發票請求，回傳 nil 表示不開立
+type InvoiceMapper func(ctx context.Context, n *PaymentNotification) (*IssueInvoiceRequest, error)
+
+// PaymentHandler 綠界付款結果通知處理器
+type PaymentHandler struct {
+	client   *Client
+	callback func(ctx context.Context, n *PaymentNotification, invoice *IssueInvoiceResponse) error
+	mapper   InvoiceMapper
+}
+
+// NewPaymentHandler 建立付款結果通知處理器
+// 通知驗證成功後呼叫 callback；若有設定 InvoiceMapper 且付款成功，
+// 會先開立發票再將結果傳入 callback，未開立時 invoice 為 nil。
+// 開立失敗或 callback 回傳錯誤時會回覆失敗，讓綠界稍後重送
+func NewPaymentHandler(client *Client, callback func(ctx context.Context, n *PaymentNotification, invoice *IssueInvoiceResponse) error) *PaymentHandler {
+	return &PaymentHandler{
+		client:   client,
+		callback: callback,
+	}
+}
+
+// SetInvoiceMapper 設定自動開立發票的轉換函式
+func (h *PaymentHandler) SetInvoiceMapper(mapper InvoiceMapper) {
+	h.mapper = mapper
+}
+
+// ServeHTTP 實作 http.Handler
+func (h *PaymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readCallback(r)
+	if err != nil {
+		h.client.writeCallbackResult(w, r, http.StatusBadRequest, err)
+		return
+	}
+	
+	n, err := h.client.ParsePaymentNotification(body)
+	if err != nil {
+		h.client.writeCallbackResult(w, r, http.StatusBadRequest, err)
+		return
+	}
+	
+	ctx := r.Context()
+	var invoice *IssueInvoiceResponse
+	if h.mapper != nil && n.Paid() {
+		invoice, err = h.issue(ctx, n)
+		if err != nil {
+			h.client.writeCallbackResult(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	
+	if h.callback != nil {
+		if err := h.callback(ctx, n, invoice); err != nil {
+			h.client.writeCallbackResult(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	
+	h.client.writeCallbackResult(w, r, http.StatusOK, nil)
+}
+
+// issue 依付款通知開立發票
+// 綠界未收到 1|OK 會重送通知，該訂單若已開立過發票（RelateNumber 重複）則回傳既有發票
+func (h *PaymentHandler) issue(ctx context.Context, n *PaymentNotification) (*IssueInvoiceResponse, error) {
+	req, err := h.mapper(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, nil
+	}
+	
+	resp, err := h.client.IssueInvoiceContext(ctx, req)
+	if !errors.Is(err, errDuplicateRelateNumber) {
+		return resp, err
+	}
+	
+	existing, getErr := h.client.GetIssueContext(ctx, &GetIssueRequest{RelateNumber: req.RelateNumber})
+	if IsError(getErr, ErrCodeNotFound) {
+		return nil, err
+	}
+	if getErr != nil {
+		return nil, getErr
+	}
+	return reconciledIssue(existing), nil
+}
+
+// ParsePaymentNotification 解析並驗證付款結果通知 (application/x-www-form-urlencoded)
+func (c *Client) ParsePaymentNotification(body []byte) (*PaymentNotification, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析付款通知失敗: %v", err))
+	}
+	
+	if !c.VerifyCheckMacValue(values) {
+		return nil, NewError(ErrCodeValidation, "CheckMacValue 驗證失敗")
+	}
+	
+	if merchantID := values.Get("MerchantID"); merchantID != c.MerchantID {
+		return nil, NewError(ErrCodeValidation, fmt.Sprintf("MerchantID 不符: %s", merchantID))
+	}
+	
+	n := &PaymentNotification{
+		MerchantID:      values.Get("MerchantID"),
+		MerchantTradeNo: values.Get("MerchantTradeNo"),
+		StoreID:         values.Get("StoreID"),
+		RtnMsg:          values.Get("RtnMsg"),
+		TradeNo:         values.Get("TradeNo"),
+		PaymentType:     values.Get("PaymentType"),
+		SimulatePaid:    values.Get("SimulatePaid") == "1",
+		CustomField1:    values.Get("CustomField1"),
+		CustomField2:    values.Get("CustomField2"),
+		CustomField3:    values.Get("CustomField3"),
+		CustomField4:    values.Get("CustomField4"),
+		Values:          values,
+	}
+	
+	if n.RtnCode, err = parseFormInt(values, "RtnCode"); err != nil {
+		return nil, err
+	}
+	if n.TradeAmt, err = parseFormInt(values, "TradeAmt"); err != nil {
+		return nil, err
+	}
+	if n.PaymentTypeChargeFee, err = parseFormInt(values, "PaymentTypeChargeFee"); err != nil {
+		return nil, err
+	}
+	
+	if n.PaymentDate, err = parseOptionalDate(values.Get("PaymentDate")); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("付款日期格式錯誤: %s", values.Get("PaymentDate")))
+	}
+	if n.TradeDate, err = parseOptionalDate(values.Get("TradeDate")); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("交易日期格式錯誤: %s", values.Get("TradeDate")))
+	}
+	
+	return n, nil
+}
+
+// parseFormInt 解析數字欄位，空值為 0
+func parseFormInt(values url.Values, key string) (int, error) {
+	v := values.Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, NewError(ErrCodeParse, fmt.Sprintf("%s 格式錯誤: %s", key, v))
+	}
+	return n, nil
+}
+
+// VerifyCheckMacValue 驗證 CheckMacValue
+func (c *Client) VerifyCheckMacValue(values url.Values) bool {
+	received := values.Get("CheckMacValue")
+	if received == "" {
+		return false
+	}
+	
+	expected := c.GenerateCheckMacValue(values)
+	return subtle.ConstantTimeCompare([]byte(strings.ToUpper(received)), []byte(expected)) == 1
+}
+
+// GenerateCheckMacValue 產生 CheckMacValue (SHA256)
+// 參數依名稱排序後前後加上 HashKey 與 HashIV，經 .NET 相容的 URL Encode 並轉小寫後取 SHA256
+func (c *Client) GenerateCheckMacValue(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k == "CheckMacValue" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+	
+	var sb strings.Builder
+	sb.WriteString("HashKey=")
+	sb.WriteString(c.HashKey)
+	for _, k := range keys {
+		sb.WriteString("&")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(values.Get(k))
+	}
+	sb.WriteString("&HashIV=")
+	sb.WriteString(c.HashIV)
+	
+	encoded := strings.ToLower(dotNetURLEncode(sb.String()))
+	sum := sha256.Sum256([]byte(encoded))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// dotNetURLEncoder 綠界以 .NET HttpUtility.UrlEncode 計算，以下字元不編碼，~ 則需編碼
+var dotNetURLEncoder = strings.NewReplacer(
+	"%2d", "-", "%2D", "-",
+	"%5f", "_", "%5F", "_",
+	"%2e", ".", "%2E", ".",
+	"%21", "!",
+	"%2a", "*", "%2A", "*",
+	"%28", "(",
+	"%29", ")",
+	"~", "%7e",
+)
+
+// dotNetURLEncode 與 .NET HttpUtility.UrlEncode 相容的 URL Encode
+func dotNetURLEncode(s string) string {
+	return dotNetURLEncoder.Replace(url.QueryEscape(s))
+}
\ No newline at end of file
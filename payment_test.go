@@ -0,0 +1,131 @@
+package ecpay_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+// postNotification 送出已簽章的付款通知，回傳處理器的回應
+func postNotification(t *testing.T, client *ecpay.Client, handler http.Handler, tradeNo string) *httptest.ResponseRecorder {
+	t.Helper()
+	
+	values := url.Values{
+		"MerchantID":      {client.MerchantID},
+		"MerchantTradeNo": {tradeNo},
+		"RtnCode":         {"1"},
+		"RtnMsg":          {"Succeeded"},
+		"TradeNo":         {"2401010000000001"},
+		"TradeAmt":        {"100"},
+		"SimulatePaid":    {"0"},
+	}
+	values.Set("CheckMacValue", client.GenerateCheckMacValue(values))
+	
+	r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(values.Encode()))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestGenerateCheckMacValue(t *testing.T) {
+	// 綠界技術文件「檢查碼機制」的範例
+	client, err := ecpay.NewClient("3002607", "pwFHCqoQZGmho4w6", "EkRm7iFT261dpevs", ecpay.Stage)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	values := url.Values{
+		"ChoosePayment":     {"ALL"},
+		"EncryptType":       {"1"},
+		"ItemName":          {"Apple iphone 15"},
+		"MerchantID":        {"3002607"},
+		"MerchantTradeDate": {"2023/03/12 15:30:23"},
+		"MerchantTradeNo":   {"ecpay20230312153023"},
+		"PaymentType":       {"aio"},
+		"ReturnURL":         {"https://www.ecpay.com.tw/receive.php"},
+		"TotalAmount":       {"30000"},
+		"TradeDesc":         {"促銷方案"},
+	}
+	if got, want := client.GenerateCheckMacValue(values), "6C51C9E6888DE861FD62FB1DD17029FC742634498FD813DC43D4243B5685B840"; got != want {
+		t.Errorf("CheckMacValue = %s, want %s", got, want)
+	}
+	
+	// .NET 會將 ~ 編碼為 %7e，Go 的 url.QueryEscape 則不編碼
+	values = url.Values{"ItemName": {"A~B"}}
+	sum := sha256.Sum256([]byte("hashkey%3dpwfhcqoqzgmho4w6%26itemname%3da%7eb%26hashiv%3dekrm7ift261dpevs"))
+	if got, want := client.GenerateCheckMacValue(values), strings.ToUpper(hex.EncodeToString(sum[:])); got != want {
+		t.Errorf("CheckMacValue with ~ = %s, want %s", got, want)
+	}
+}
+
+func TestPaymentHandlerResentNotification(t *testing.T) {
+	server, client := newTestClient(t)
+	
+	// 第一次通知時 callback 失敗，綠界稍後重送同一筆通知
+	var invoices []*ecpay.IssueInvoiceResponse
+	handler := ecpay.NewPaymentHandler(client, func(ctx context.Context, n *ecpay.PaymentNotification, invoice *ecpay.IssueInvoiceResponse) error {
+		invoices = append(invoices, invoice)
+		if len(invoices) == 1 {
+			return errors.New("訂單資料庫連線中斷")
+		}
+		return nil
+	})
+	handler.SetInvoiceMapper(func(ctx context.Context, n *ecpay.PaymentNotification) (*ecpay.IssueInvoiceRequest, error) {
+		return testIssueRequest(n.MerchantTradeNo), nil
+	})
+	
+	w := postNotification(t, client, handler, "T0001")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "0|Error" {
+		t.Fatalf("first notification = %d %q, want 500 \"0|Error\"", w.Code, w.Body.String())
+	}
+	
+	w = postNotification(t, client, handler, "T0001")
+	if w.Code != http.StatusOK || w.Body.String() != "1|OK" {
+		t.Fatalf("resent notification = %d %q, want 200 \"1|OK\"", w.Code, w.Body.String())
+	}
+	
+	if len(invoices) != 2 || invoices[1].InvoiceNo != invoices[0].InvoiceNo || !invoices[1].Reconciled {
+		t.Fatalf("invoices = %+v, want the first invoice reconciled", invoices)
+	}
+	if _, ok := server.InvoiceByRelateNumber("T0001"); !ok {
+		t.Fatal("invoice not issued")
+	}
+}
+
+func TestPaymentHandlerIssueRejected(t *testing.T) {
+	server, client := newTestClient(t)
+	
+	called := false
+	handler := ecpay.NewPaymentHandler(client, func(ctx context.Context, n *ecpay.PaymentNotification, invoice *ecpay.IssueInvoiceResponse) error {
+		called = true
+		return nil
+	})
+	handler.SetInvoiceMapper(func(ctx context.Context, n *ecpay.PaymentNotification) (*ecpay.IssueInvoiceRequest, error) {
+		return testIssueRequest(n.MerchantTradeNo), nil
+	})
+	
+	// 綠界拒絕開立（非 RelateNumber 重複）時不查詢既有發票
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{
+		Kind: ecpaytest.FaultRtnCode,
+		Code: ecpaytest.RtnCodeFailed,
+		Msg:  "資料錯誤",
+	})
+	w := postNotification(t, client, handler, "T0001")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "0|Error" {
+		t.Fatalf("notification = %d %q, want 500 \"0|Error\"", w.Code, w.Body.String())
+	}
+	if called {
+		t.Error("callback called after the issue was rejected")
+	}
+	if calls := server.Calls("/B2CInvoice/GetIssue"); calls != 0 {
+		t.Errorf("GetIssue calls = %d, want 0", calls)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,145 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// InvoicePrint 取得發票列印網址
+func (c *Client) InvoicePrint(req *InvoicePrintRequest) (*InvoicePrintResponse, error) {
+	return c.InvoicePrintContext(context.Background(), req)
+}
+
+// InvoicePrintContext 取得發票列印網址（可透過 ctx 取消或設定期限）
+func (c *Client) InvoicePrintContext(ctx context.Context, req *InvoicePrintRequest) (_ *InvoicePrintResponse, err error) {
+	ctx, span := c.startMethod(ctx, "InvoicePrint", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/InvoicePrint", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp InvoicePrintResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析發票列印回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// DownloadInvoicePDF 下載發票證明聯 PDF 並寫入 w，回傳寫入的位元組數
+func (c *Client) DownloadInvoicePDF(req *InvoicePrintRequest, w io.Writer) (int64, error) {
+	return c.DownloadInvoicePDFContext(context.Background(), req, w)
+}
+
+// DownloadInvoicePDFContext 下載發票證明聯 PDF 並寫入 w（可透過 ctx 取消或設定期限）
+// 透過客戶端的 httpClient 呼叫 /B2CInvoice/InvoicePrintPDF，列印格式與 InvoicePrint 相同；
+// 綠界無法產生 PDF 時回傳 ErrCodeAPI，且不會寫入 w
+func (c *Client) DownloadInvoicePDFContext(ctx context.Context, req *InvoicePrintRequest, w io.Writer) (_ int64, err error) {
+	ctx, span := c.startMethod(ctx, "DownloadInvoicePDF", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+	
+	// 發送請求
+	return c.downloadPDF(ctx, "/B2CInvoice/InvoicePrintPDF", req, w)
+}
+
+// downloadPDF 發送 API 請求並將回傳的 PDF 寫入 w
+// 成功時綠界直接回傳 PDF 檔案，失敗時回傳與其他 API 相同的加密封包
+func (c *Client) downloadPDF(ctx context.Context, apiPath string, data interface{}, w io.Writer) (_ int64, err error) {
+	ctx, span := c.startSpan(ctx, apiPath, data)
+	var respData []byte
+	defer func() {
+		endSpan(span, respData, err)
+	}()
+	
+	var transCode int
+	start := time.Now()
+	defer func() {
+		c.logRequest(ctx, apiPath, data, respData, transCode, time.Since(start), err)
+	}()
+	
+	if err := ctx.Err(); err != nil {
+		return 0, contextError(err)
+	}
+	
+	req, err := c.newAPIRequest(ctx, apiPath, data)
+	if err != nil {
+		return 0, err
+	}
+	
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, contextError(ctxErr)
+		}
+		return 0, NewError(ErrCodeNetwork, fmt.Sprintf("發送請求失敗: %v", err))
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return 0, NewError(ErrCodeResponse, fmt.Sprintf("下載發票 PDF 失敗: HTTP %d", resp.StatusCode))
+	}
+	
+	// 寫入 PDF
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/pdf" {
+		n, err := io.Copy(w, resp.Body)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return n, contextError(ctxErr)
+			}
+			return n, NewError(ErrCodeResponse, fmt.Sprintf("讀取發票 PDF 失敗: %v", err))
+		}
+		return n, nil
+	}
+	
+	// 解析錯誤回應
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, NewError(ErrCodeResponse, fmt.Sprintf("讀取回應失敗: %v", err))
+	}
+	respData, transCode, err = c.decodeResponse(ctx, body)
+	if err != nil {
+		return 0, err
+	}
+	
+	var result struct {
+		RtnCode flexNumber `json:"RtnCode"`
+		RtnMsg  string     `json:"RtnMsg"`
+	}
+	if err := json.Unmarshal(respData, &result); err != nil {
+		return 0, NewError(ErrCodeParse, fmt.Sprintf("解析發票 PDF 回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if result.RtnCode.Int() != 1 {
+		return 0, NewError(ErrCodeAPI, result.RtnMsg)
+	}
+	return 0, NewError(ErrCodeResponse, "綠界未回傳 PDF")
+}
\ No newline at end of file
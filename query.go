@@ -0,0 +1,684 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// Invoice 發票資料
+type Invoice struct {
+	MerchantID   string
+	InvoiceNo    string
+	InvoiceDate  time.Time
+	RelateNumber string
+	RandomNumber string
+	Category     string // B2C / B2B
+	
+	// 買受人
+	CustomerID         string
+	CustomerIdentifier string
+	CustomerName       string
+	CustomerAddr       string
+	CustomerPhone      string
+	CustomerEmail      string
+	ClearanceMark      string
+	
+	// 稅務與金額
+	InvType                  string
+	TaxType                  string
+	TaxRate                  float64
+	TaxAmount                int
+	SalesAmount              int
+	RemainingAllowanceAmount int
+	
+	// 載具與捐贈
+	CarrierType string
+	CarrierNum  string
+	Donation    bool
+	LoveCode    string
+	PrintFlag   string
+	
+	// 狀態
+	Status       string // InvoiceStatusNormal / InvoiceStatusInvalid
+	IssueStatus  string // 開立狀態 1: 已開立 0: 已註銷
+	UploadStatus string // UploadStatusYes / UploadStatusNo
+	UploadDate   time.Time
+	AwardFlag    string
+	Remark       string
+	
+	Items []Item
+	
+	// 列印資訊
+	CheckNumber string
+	PosBarCode  string
+	QRCodeLeft  string
+	QRCodeRight string
+}
+
+// IsInvalid 發票是否已作廢
+func (inv *Invoice) IsInvalid() bool {
+	return inv.Status == InvoiceStatusInvalid
+}
+
+// IsUploaded 發票是否已上傳至財政部
+func (inv *Invoice) IsUploaded() bool {
+	return inv.UploadStatus == UploadStatusYes
+}
+
+// invoiceItemData 查詢回應中的商品明細，數量與單價可能為小數或字串
+type invoiceItemData struct {
+	ItemSeq     flexNumber `json:"ItemSeq"`
+	ItemName    string     `json:"ItemName"`
+	ItemCount   flexNumber `json:"ItemCount"`
+	ItemWord    string     `json:"ItemWord"`
+	ItemPrice   flexNumber `json:"ItemPrice"`
+	ItemTaxType string     `json:"ItemTaxType"`
+	ItemAmount  flexNumber `json:"ItemAmount"`
+	ItemRemark  string     `json:"ItemRemark"`
+}
+
+// invoiceData 查詢發票回應資料
+type invoiceData struct {
+	RtnCode               int               `json:"RtnCode"`
+	RtnMsg                string            `json:"RtnMsg"`
+	MerchantID            string            `json:"IIS_Mer_ID"`
+	InvoiceNo             string            `json:"IIS_Number"`
+	RelateNumber          string            `json:"IIS_Relate_Number"`
+	CustomerID            string            `json:"IIS_Customer_ID"`
+	Identifier            string            `json:"IIS_Identifier"`
+	CustomerName          string            `json:"IIS_Customer_Name"`
+	CustomerAddr          string            `json:"IIS_Customer_Addr"`
+	CustomerPhone         string            `json:"IIS_Customer_Phone"`
+	CustomerEmail         string            `json:"IIS_Customer_Email"`
+	ClearanceMark         string            `json:"IIS_Clearance_Mark"`
+	Type                  string            `json:"IIS_Type"`
+	Category              string            `json:"IIS_Category"`
+	TaxType               string            `json:"IIS_Tax_Type"`
+	TaxRate               flexNumber        `json:"IIS_Tax_Rate"`
+	TaxAmount             flexNumber        `json:"IIS_Tax_Amount"`
+	SalesAmount           flexNumber        `json:"IIS_Sales_Amount"`
+	CheckNumber           string            `json:"IIS_Check_Number"`
+	CarrierType           string            `json:"IIS_Carrier_Type"`
+	CarrierNum            string            `json:"IIS_Carrier_Num"`
+	LoveCode              string            `json:"IIS_Love_Code"`
+	CreateDate            string            `json:"IIS_Create_Date"`
+	IssueStatus           string            `json:"IIS_Issue_Status"`
+	InvalidStatus         string            `json:"IIS_Invalid_Status"`
+	UploadStatus          string            `json:"IIS_Upload_Status"`
+	UploadDate            string            `json:"IIS_Upload_Date"`
+	RemainAllowanceAmount flexNumber        `json:"IIS_Remain_Allowance_Amt"`
+	PrintFlag             string            `json:"IIS_Print_Flag"`
+	AwardFlag             string            `json:"IIS_Award_Flag"`
+	RandomNumber          string            `json:"IIS_Random_Number"`
+	InvoiceRemark         string            `json:"InvoiceRemark"`
+	Items                 []invoiceItemData `json:"Items"`
+	PosBarCode            string            `json:"PosBarCode"`
+	QRCodeLeft            string            `json:"QRCode_Left"`
+	QRCodeRight           string            `json:"QRCode_Right"`
+}
+
+// toInvoice 轉換為 Invoice
+func (d *invoiceData) toInvoice() (*Invoice, error) {
+	invoiceDate, err := parseOptionalDate(d.CreateDate)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("發票日期格式錯誤: %s", d.CreateDate))
+	}
+	
+	uploadDate, err := parseOptionalDate(d.UploadDate)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("上傳日期格式錯誤: %s", d.UploadDate))
+	}
+	
+	inv := &Invoice{
+		MerchantID:               d.MerchantID,
+		InvoiceNo:                d.InvoiceNo,
+		InvoiceDate:              invoiceDate,
+		RelateNumber:             d.RelateNumber,
+		RandomNumber:             d.RandomNumber,
+		Category:                 d.Category,
+		CustomerID:               d.CustomerID,
+		CustomerIdentifier:       d.Identifier,
+		CustomerName:             d.CustomerName,
+		CustomerAddr:             d.CustomerAddr,
+		CustomerPhone:            d.CustomerPhone,
+		CustomerEmail:            d.CustomerEmail,
+		ClearanceMark:            d.ClearanceMark,
+		InvType:                  d.Type,
+		TaxType:                  d.TaxType,
+		TaxRate:                  float64(d.TaxRate),
+		TaxAmount:                d.TaxAmount.Int(),
+		SalesAmount:              d.SalesAmount.Int(),
+		RemainingAllowanceAmount: d.RemainAllowanceAmount.Int(),
+		CarrierType:              d.CarrierType,
+		CarrierNum:               d.CarrierNum,
+		Donation:                 d.LoveCode != "",
+		LoveCode:                 d.LoveCode,
+		PrintFlag:                d.PrintFlag,
+		Status:                   invalidStatus(d.InvalidStatus),
+		IssueStatus:              d.IssueStatus,
+		UploadStatus:             uploadStatus(d.UploadStatus),
+		UploadDate:               uploadDate,
+		AwardFlag:                d.AwardFlag,
+		Remark:                   d.InvoiceRemark,
+		CheckNumber:              d.CheckNumber,
+		PosBarCode:               d.PosBarCode,
+		QRCodeLeft:               d.QRCodeLeft,
+		QRCodeRight:              d.QRCodeRight,
+		Items:                    toItems(d.Items),
+	}
+	
+	return inv, nil
+}
+
+// GetIssue 查詢發票
+// 查無發票時回傳 ErrCodeNotFound
+func (c *Client) GetIssue(req *GetIssueRequest) (*Invoice, error) {
+	return c.GetIssueContext(context.Background(), req)
+}
+
+// GetIssueContext 查詢發票（可透過 ctx 取消或設定期限）
+func (c *Client) GetIssueContext(ctx context.Context, req *GetIssueRequest) (_ *Invoice, err error) {
+	ctx, span := c.startMethod(ctx, "GetIssue", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	return c.getIssue(ctx, req, c.sendRequest)
+}
+
+// getIssue 以 send 發送查詢發票請求，重試流程內的查詢以 sendOnce 發送避免重試次數相乘
+func (c *Client) getIssue(ctx context.Context, req *GetIssueRequest, send sendFunc) (*Invoice, error) {
+	// 發送請求
+	respData, err := send(ctx, "/B2CInvoice/GetIssue", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp invoiceData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析查詢發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode == rtnCodeNotFound {
+		return nil, NewError(ErrCodeNotFound, resp.RtnMsg)
+	}
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return resp.toInvoice()
+}
+
+// invoiceListData 查詢發票清單回應資料
+type invoiceListData struct {
+	RtnCode     int           `json:"RtnCode"`
+	RtnMsg      string        `json:"RtnMsg"`
+	TotalCount  int           `json:"TotalCount"`
+	ShowingPage int           `json:"ShowingPage"`
+	InvoiceData []invoiceData `json:"InvoiceData"`
+}
+
+// ListInvoices 查詢發票清單
+// 回傳的 iterator 會在迭代時逐頁向綠界取得資料，呼叫端 break 後即停止請求
+// 發生錯誤時會回傳一次非 nil 的 error 並結束迭代
+func (c *Client) ListInvoices(ctx context.Context, filter *InvoiceListFilter) iter.Seq2[Invoice, error] {
+	return func(yield func(Invoice, error) bool) {
+		ctx, span := c.startMethod(ctx, "ListInvoices", nil)
+		defer span.End()
+		
+		// 驗證請求
+		if err := filter.Validate(); err != nil {
+			recordError(span, err)
+			yield(Invoice{}, err)
+			return
+		}
+		
+		req := getIssueListRequest{
+			BeginDate:   FormatInvoiceDate(filter.BeginDate),
+			EndDate:     FormatInvoiceDate(filter.EndDate),
+			NumPerPage:  filter.PageSize,
+			DataType:    "1",
+			QueryUpload: filter.UploadStatus,
+			FormatType:  "1",
+		}
+		if req.NumPerPage == 0 {
+			req.NumPerPage = 200
+		}
+		
+		// Query_Invalid 1 為已作廢，與 InvoiceStatus* 相反
+		switch filter.InvalidStatus {
+		case InvoiceStatusNormal:
+			req.QueryInvalid = "0"
+		case InvoiceStatusInvalid:
+			req.QueryInvalid = "1"
+		}
+		
+		fetched := 0
+		for page := 1; ; page++ {
+			req.ShowingPage = page
+			resp, err := c.getIssueListPage(ctx, &req)
+			if err != nil {
+				recordError(span, err)
+				yield(Invoice{}, err)
+				return
+			}
+			
+			for i := range resp.InvoiceData {
+				inv, err := resp.InvoiceData[i].toInvoice()
+				if err != nil {
+					recordError(span, err)
+					yield(Invoice{}, err)
+					return
+				}
+				
+				if filter.CarrierType != CarrierTypeNone && inv.CarrierType != filter.CarrierType {
+					continue
+				}
+				
+				if !yield(*inv, nil) {
+					return
+				}
+			}
+			
+			fetched += len(resp.InvoiceData)
+			if len(resp.InvoiceData) == 0 || fetched >= resp.TotalCount {
+				return
+			}
+		}
+	}
+}
+
+// getIssueListPage 查詢發票清單單頁資料
+func (c *Client) getIssueListPage(ctx context.Context, req *getIssueListRequest) (*invoiceListData, error) {
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetIssueList", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp invoiceListData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析發票清單回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// InvalidRecord 作廢發票資料
+type InvalidRecord struct {
+	InvoiceNo          string
+	InvalidDate        time.Time
+	Reason             string
+	SellerIdentifier   string
+	CustomerIdentifier string
+	UploadStatus       string // UploadStatusYes / UploadStatusNo
+	UploadDate         time.Time
+}
+
+// invalidData 查詢作廢發票回應資料
+type invalidData struct {
+	RtnCode          int    `json:"RtnCode"`
+	RtnMsg           string `json:"RtnMsg"`
+	InvoiceNo        string `json:"II_Invoice_No"`
+	Date             string `json:"II_Date"`
+	UploadStatus     string `json:"II_Upload_Status"`
+	UploadDate       string `json:"II_Upload_Date"`
+	SellerIdentifier string `json:"II_Seller_Identifier"`
+	BuyerIdentifier  string `json:"II_Buyer_Identifier"`
+	Reason           string `json:"Reason"`
+}
+
+// GetInvalid 查詢作廢發票
+func (c *Client) GetInvalid(req *GetInvalidRequest) (*InvalidRecord, error) {
+	return c.GetInvalidContext(context.Background(), req)
+}
+
+// GetInvalidContext 查詢作廢發票（可透過 ctx 取消或設定期限）
+func (c *Client) GetInvalidContext(ctx context.Context, req *GetInvalidRequest) (_ *InvalidRecord, err error) {
+	ctx, span := c.startMethod(ctx, "GetInvalid", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetInvalid", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp invalidData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析作廢發票回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	invalidDate, err := parseOptionalDate(resp.Date)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("作廢日期格式錯誤: %s", resp.Date))
+	}
+	
+	uploadDate, err := parseOptionalDate(resp.UploadDate)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("上傳日期格式錯誤: %s", resp.UploadDate))
+	}
+	
+	return &InvalidRecord{
+		InvoiceNo:          resp.InvoiceNo,
+		InvalidDate:        invalidDate,
+		Reason:             resp.Reason,
+		SellerIdentifier:   resp.SellerIdentifier,
+		CustomerIdentifier: resp.BuyerIdentifier,
+		UploadStatus:       uploadStatus(resp.UploadStatus),
+		UploadDate:         uploadDate,
+	}, nil
+}
+
+// AllowanceRecord 折讓資料
+type AllowanceRecord struct {
+	AllowanceNo              string
+	InvoiceNo                string
+	AllowanceDate            time.Time
+	CustomerName             string
+	CustomerIdentifier       string
+	SellerIdentifier         string
+	TaxAmount                int
+	TotalAmount              int
+	RemainingAllowanceAmount int
+	Status                   string // InvoiceStatusNormal / InvoiceStatusInvalid
+	UploadStatus             string // UploadStatusYes / UploadStatusNo
+	UploadDate               time.Time
+	Items                    []Item
+}
+
+// IsInvalid 折讓是否已作廢
+func (a *AllowanceRecord) IsInvalid() bool {
+	return a.Status == InvoiceStatusInvalid
+}
+
+// allowanceData 查詢折讓明細回應中的單筆折讓
+type allowanceData struct {
+	AllowNo               string            `json:"IA_Allow_No"`
+	InvoiceNo             string            `json:"IA_Invoice_No"`
+	Date                  string            `json:"IA_Date"`
+	CustomerName          string            `json:"IA_Customer_Name"`
+	BuyerIdentifier       string            `json:"IA_Buyer_Identifier"`
+	SellerIdentifier      string            `json:"IA_Seller_Identifier"`
+	TaxAmount             flexNumber        `json:"IA_Tax_Amount"`
+	TotalAmount           flexNumber        `json:"IA_Total_Amount"`
+	RemainAllowanceAmount flexNumber        `json:"IA_Remain_Allowance_Amt"`
+	InvalidStatus         string            `json:"IA_Invalid_Status"`
+	UploadStatus          string            `json:"IA_Upload_Status"`
+	UploadDate            string            `json:"IA_Upload_Date"`
+	Items                 []invoiceItemData `json:"Items"`
+}
+
+// toAllowanceRecord 轉換為 AllowanceRecord
+func (d *allowanceData) toAllowanceRecord() (*AllowanceRecord, error) {
+	allowanceDate, err := parseOptionalDate(d.Date)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("折讓日期格式錯誤: %s", d.Date))
+	}
+	
+	uploadDate, err := parseOptionalDate(d.UploadDate)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("上傳日期格式錯誤: %s", d.UploadDate))
+	}
+	
+	record := &AllowanceRecord{
+		AllowanceNo:              d.AllowNo,
+		InvoiceNo:                d.InvoiceNo,
+		AllowanceDate:            allowanceDate,
+		CustomerName:             d.CustomerName,
+		CustomerIdentifier:       d.BuyerIdentifier,
+		SellerIdentifier:         d.SellerIdentifier,
+		TaxAmount:                d.TaxAmount.Int(),
+		TotalAmount:              d.TotalAmount.Int(),
+		RemainingAllowanceAmount: d.RemainAllowanceAmount.Int(),
+		Status:                   invalidStatus(d.InvalidStatus),
+		UploadStatus:             uploadStatus(d.UploadStatus),
+		UploadDate:               uploadDate,
+		Items:                    toItems(d.Items),
+	}
+	
+	return record, nil
+}
+
+// allowanceListData 查詢折讓明細回應資料
+type allowanceListData struct {
+	RtnCode       int             `json:"RtnCode"`
+	RtnMsg        string          `json:"RtnMsg"`
+	AllowanceInfo []allowanceData `json:"AllowanceInfo"`
+}
+
+// GetAllowanceList 查詢折讓明細
+func (c *Client) GetAllowanceList(req *GetAllowanceListRequest) ([]AllowanceRecord, error) {
+	return c.GetAllowanceListContext(context.Background(), req)
+}
+
+// GetAllowanceListContext 查詢折讓明細（可透過 ctx 取消或設定期限）
+func (c *Client) GetAllowanceListContext(ctx context.Context, req *GetAllowanceListRequest) (_ []AllowanceRecord, err error) {
+	ctx, span := c.startMethod(ctx, "GetAllowanceList", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetAllowanceList", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp allowanceListData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析折讓明細回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	records := make([]AllowanceRecord, 0, len(resp.AllowanceInfo))
+	for i := range resp.AllowanceInfo {
+		record, err := resp.AllowanceInfo[i].toAllowanceRecord()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	
+	return records, nil
+}
+
+// AllowanceInvalidRecord 作廢折讓資料
+type AllowanceInvalidRecord struct {
+	AllowanceNo        string
+	InvoiceNo          string
+	InvalidDate        time.Time
+	Reason             string
+	SellerIdentifier   string
+	CustomerIdentifier string
+	UploadStatus       string // UploadStatusYes / UploadStatusNo
+	UploadDate         time.Time
+}
+
+// allowanceInvalidData 查詢作廢折讓回應資料
+type allowanceInvalidData struct {
+	RtnCode          int    `json:"RtnCode"`
+	RtnMsg           string `json:"RtnMsg"`
+	AllowNo          string `json:"AI_Allow_No"`
+	InvoiceNo        string `json:"AI_Invoice_No"`
+	Date             string `json:"AI_Date"`
+	UploadStatus     string `json:"AI_Upload_Status"`
+	UploadDate       string `json:"AI_Upload_Date"`
+	SellerIdentifier string `json:"AI_Seller_Identifier"`
+	BuyerIdentifier  string `json:"AI_Buyer_Identifier"`
+	Reason           string `json:"Reason"`
+}
+
+// GetAllowanceInvalid 查詢作廢折讓
+func (c *Client) GetAllowanceInvalid(req *GetAllowanceInvalidRequest) (*AllowanceInvalidRecord, error) {
+	return c.GetAllowanceInvalidContext(context.Background(), req)
+}
+
+// GetAllowanceInvalidContext 查詢作廢折讓（可透過 ctx 取消或設定期限）
+func (c *Client) GetAllowanceInvalidContext(ctx context.Context, req *GetAllowanceInvalidRequest) (_ *AllowanceInvalidRecord, err error) {
+	ctx, span := c.startMethod(ctx, "GetAllowanceInvalid", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetAllowanceInvalid", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp allowanceInvalidData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析作廢折讓回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	invalidDate, err := parseOptionalDate(resp.Date)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("作廢日期格式錯誤: %s", resp.Date))
+	}
+	
+	uploadDate, err := parseOptionalDate(resp.UploadDate)
+	if err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("上傳日期格式錯誤: %s", resp.UploadDate))
+	}
+	
+	return &AllowanceInvalidRecord{
+		AllowanceNo:        resp.AllowNo,
+		InvoiceNo:          resp.InvoiceNo,
+		InvalidDate:        invalidDate,
+		Reason:             resp.Reason,
+		SellerIdentifier:   resp.SellerIdentifier,
+		CustomerIdentifier: resp.BuyerIdentifier,
+		UploadStatus:       uploadStatus(resp.UploadStatus),
+		UploadDate:         uploadDate,
+	}, nil
+}
+
+// invalidStatus 將綠界作廢註記（1 為已作廢）轉換為 InvoiceStatus*
+func invalidStatus(flag string) string {
+	if flag == "1" {
+		return InvoiceStatusInvalid
+	}
+	return InvoiceStatusNormal
+}
+
+// uploadStatus 將綠界上傳註記轉換為 UploadStatus*
+func uploadStatus(flag string) string {
+	if flag == UploadStatusYes {
+		return UploadStatusYes
+	}
+	return UploadStatusNo
+}
+
+// toItems 轉換查詢回應中的商品明細
+func toItems(data []invoiceItemData) []Item {
+	var items []Item
+	for _, item := range data {
+		items = append(items, Item{
+			ItemSeq:     item.ItemSeq.Int(),
+			ItemName:    item.ItemName,
+			ItemCount:   item.ItemCount.Int(),
+			ItemWord:    item.ItemWord,
+			ItemPrice:   item.ItemPrice.Int(),
+			ItemTaxType: item.ItemTaxType,
+			ItemAmount:  item.ItemAmount.Int(),
+			ItemRemark:  item.ItemRemark,
+		})
+	}
+	return items
+}
+
+// GetCompanyNameByTaxID 以統一編號查詢公司名稱
+// 查無資料時回傳 ErrCodeNotFound
+func (c *Client) GetCompanyNameByTaxID(taxID string) (string, error) {
+	return c.GetCompanyNameByTaxIDContext(context.Background(), taxID)
+}
+
+// GetCompanyNameByTaxIDContext 以統一編號查詢公司名稱（可透過 ctx 取消或設定期限）
+func (c *Client) GetCompanyNameByTaxIDContext(ctx context.Context, taxID string) (_ string, err error) {
+	ctx, span := c.startMethod(ctx, "GetCompanyNameByTaxID", nil)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 檢查碼錯誤的統編不需送出
+	if !ValidateTaxID(taxID) {
+		return "", NewError(ErrCodeValidation, "統一編號格式不正確")
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetCompanyNameByTaxID", &getCompanyNameRequest{UnifiedBusinessNo: taxID})
+	if err != nil {
+		return "", err
+	}
+	
+	// 解析回應
+	var resp getCompanyNameResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return "", NewError(ErrCodeParse, fmt.Sprintf("解析公司名稱回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode == rtnCodeNotFound {
+		return "", NewError(ErrCodeNotFound, fmt.Sprintf("查無統一編號 %s 的公司名稱", taxID))
+	}
+	if resp.RtnCode != 1 {
+		return "", NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	if resp.CompanyName == "" {
+		return "", NewError(ErrCodeNotFound, fmt.Sprintf("查無統一編號 %s 的公司名稱", taxID))
+	}
+	
+	return resp.CompanyName, nil
+}
\ No newline at end of file
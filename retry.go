@@ -0,0 +1,163 @@
+package ecpay
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+	
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetryPolicy 自動重試策略
+type RetryPolicy struct {
+	MaxAttempts int           // 最多嘗試次數（含第一次）
+	BaseDelay   time.Duration // 第一次重試前的等待時間，之後每次加倍
+	MaxDelay    time.Duration // 單次等待時間上限，0 表示不限
+	Jitter      float64       // 等待時間的隨機浮動比例（0~1），避免大量請求同時重送
+}
+
+// DefaultRetryPolicy 預設重試策略：最多 3 次，等待 500ms、1s，浮動 20%
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// backoff 第 attempt 次嘗試失敗後的等待時間
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	
+	if p.Jitter > 0 {
+		delay += time.Duration(float64(delay) * p.Jitter * (rand.Float64()*2 - 1))
+	}
+	return delay
+}
+
+// readOnlyAPIs 不會變更資料、可直接重送的 API
+var readOnlyAPIs = map[string]bool{
+	"/B2CInvoice/GetIssue":                 true,
+	"/B2CInvoice/GetIssueList":             true,
+	"/B2CInvoice/GetInvalid":               true,
+	"/B2CInvoice/GetAllowanceList":         true,
+	"/B2CInvoice/GetAllowanceInvalid":      true,
+	"/B2CInvoice/GetCompanyNameByTaxID":    true,
+	"/B2CInvoice/CheckBarcode":             true,
+	"/B2CInvoice/CheckLoveCode":            true,
+	"/B2CInvoice/InvoicePrint":             true,
+	"/B2CInvoice/GetGovInvoiceWordSetting": true,
+	"/B2CInvoice/GetInvoiceWordSetting":    true,
+	"/B2BInvoice/GetIssue":                 true,
+	"/B2BInvoice/GetIssueList":             true,
+}
+
+// retryable 是否為可重試的暫時性錯誤
+func retryable(err error) bool {
+	return IsError(err, ErrCodeNetwork) || IsError(err, ErrCodeResponse)
+}
+
+// withRetry 依重試策略執行 fn，回傳的錯誤會記錄總嘗試次數
+func (c *Client) withRetry(ctx context.Context, fn func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		
+		if attempt >= c.retry.MaxAttempts || !retryable(err) {
+			return withAttempts(err, attempt)
+		}
+		
+		timer := time.NewTimer(c.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return withAttempts(contextError(ctx.Err()), attempt)
+		case <-timer.C:
+		}
+	}
+}
+
+// withAttempts 在錯誤上記錄嘗試次數
+func withAttempts(err error, attempts int) error {
+	var e *Error
+	if errors.As(err, &e) {
+		e.Attempts = attempts
+	}
+	return err
+}
+
+// errDuplicateRelateNumber 綠界回應 RelateNumber 重複
+var errDuplicateRelateNumber = errors.New("RelateNumber 重複")
+
+// issueWithRetry 依重試策略開立發票
+// 網路錯誤時無法得知綠界是否已開立，重送前先以 RelateNumber 查詢：
+// 查得發票即視為成功（Reconciled 為 true），查無資料（ErrCodeNotFound）才重送；
+// 查詢失敗時下次嘗試會再查詢，不會在未確認前重送。
+// 重送後綠界回應 RelateNumber 重複，表示前次請求在查詢後才完成，再查詢一次取得該發票。
+// 嘗試次數與是否對帳記錄在 ctx 中 IssueInvoice 的方法 span 上
+func (c *Client) issueWithRetry(ctx context.Context, req *IssueInvoiceRequest) (*IssueInvoiceResponse, error) {
+	span := trace.SpanFromContext(ctx)
+	
+	var resp *IssueInvoiceResponse
+	err := c.withRetry(ctx, func(attempt int) error {
+		span.SetAttributes(attrAttempts.Int(attempt))
+		if attempt > 1 {
+			existing, err := c.reconcileIssue(ctx, req.RelateNumber)
+			switch {
+			case err == nil:
+				resp = reconciledIssue(existing)
+				return nil
+			case !IsError(err, ErrCodeNotFound):
+				return err
+			}
+		}
+		
+		var err error
+		resp, err = c.issueInvoice(ctx, req)
+		if attempt > 1 && errors.Is(err, errDuplicateRelateNumber) {
+			existing, getErr := c.reconcileIssue(ctx, req.RelateNumber)
+			if IsError(getErr, ErrCodeNotFound) {
+				return err
+			}
+			if getErr != nil {
+				return getErr
+			}
+			resp = reconciledIssue(existing)
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	span.SetAttributes(attrReconciled.Bool(resp.Reconciled))
+	return resp, nil
+}
+
+// reconcileIssue 以 RelateNumber 查詢前次開立的發票，只發送一次，由外層的重試流程決定是否再查詢
+func (c *Client) reconcileIssue(ctx context.Context, relateNumber string) (*Invoice, error) {
+	return c.getIssue(ctx, &GetIssueRequest{RelateNumber: relateNumber}, c.sendOnce)
+}
+
+// reconciledIssue 以查詢到的既有發票作為開立結果
+func reconciledIssue(inv *Invoice) *IssueInvoiceResponse {
+	return &IssueInvoiceResponse{
+		RtnCode:      1,
+		RtnMsg:       "前次請求已開立",
+		InvoiceNo:    inv.InvoiceNo,
+		InvoiceDate:  inv.InvoiceDate.Format("2006-01-02 15:04:05"),
+		RandomNumber: inv.RandomNumber,
+		Reconciled:   true,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,150 @@
+package ecpay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+)
+
+// newRetryClient 建立啟用重試的模擬伺服器與客戶端，逾時設短以便模擬回應遺失
+func newRetryClient(t *testing.T) (*ecpaytest.Server, *ecpay.Client) {
+	t.Helper()
+	
+	server, client := newTestClient(t, ecpay.WithTimeout(200*time.Millisecond))
+	client.SetRetryPolicy(&ecpay.RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond})
+	return server, client
+}
+
+func TestIssueRetryLostResponse(t *testing.T) {
+	server, client := newRetryClient(t)
+	
+	// 綠界已開立但回應遺失，重試時查得發票，不重送
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{Kind: ecpaytest.FaultLateResponse})
+	resp, err := client.IssueInvoice(testIssueRequest("R001"))
+	if err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	
+	inv, _ := server.InvoiceByRelateNumber("R001")
+	if !resp.Reconciled || resp.InvoiceNo != inv.InvoiceNo {
+		t.Errorf("resp = %+v, want reconciled %s", resp, inv.InvoiceNo)
+	}
+	if calls := server.Calls("/B2CInvoice/Issue"); calls != 1 {
+		t.Errorf("Issue calls = %d, want 1", calls)
+	}
+	if calls := server.Calls("/B2CInvoice/GetIssue"); calls != 1 {
+		t.Errorf("GetIssue calls = %d, want 1", calls)
+	}
+}
+
+func TestIssueRetryNotFound(t *testing.T) {
+	server, client := newRetryClient(t)
+	
+	// 綠界未處理即逾時，查無發票才重送
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{Kind: ecpaytest.FaultDelay, Delay: time.Second})
+	resp, err := client.IssueInvoice(testIssueRequest("R001"))
+	if err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	
+	if resp.Reconciled {
+		t.Error("resp reconciled, want a fresh issue")
+	}
+	if calls := server.Calls("/B2CInvoice/Issue"); calls != 2 {
+		t.Errorf("Issue calls = %d, want 2", calls)
+	}
+	if calls := server.Calls("/B2CInvoice/GetIssue"); calls != 1 {
+		t.Errorf("GetIssue calls = %d, want 1", calls)
+	}
+}
+
+func TestIssueRetryDuplicate(t *testing.T) {
+	server, client := newRetryClient(t)
+	
+	// 查詢時綠界尚未完成前次開立，重送得到 RelateNumber 重複後再查詢一次
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{Kind: ecpaytest.FaultLateResponse})
+	server.InjectFault("/B2CInvoice/GetIssue", 1, ecpaytest.Fault{
+		Kind: ecpaytest.FaultRtnCode,
+		Code: ecpaytest.RtnCodeNotFound,
+		Msg:  "查無發票資料",
+	})
+	resp, err := client.IssueInvoice(testIssueRequest("R001"))
+	if err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	
+	inv, _ := server.InvoiceByRelateNumber("R001")
+	if !resp.Reconciled || resp.InvoiceNo != inv.InvoiceNo {
+		t.Errorf("resp = %+v, want reconciled %s", resp, inv.InvoiceNo)
+	}
+	if calls := server.Calls("/B2CInvoice/Issue"); calls != 2 {
+		t.Errorf("Issue calls = %d, want 2", calls)
+	}
+	if calls := server.Calls("/B2CInvoice/GetIssue"); calls != 2 {
+		t.Errorf("GetIssue calls = %d, want 2", calls)
+	}
+}
+
+func TestIssueRetryReconcileUnavailable(t *testing.T) {
+	server, client := newRetryClient(t)
+	
+	// 查詢一直失敗時不重送，每次嘗試只查詢一次
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{Kind: ecpaytest.FaultLateResponse})
+	server.InjectFault("/B2CInvoice/GetIssue", 0, ecpaytest.Fault{Kind: ecpaytest.FaultTruncate})
+	_, err := client.IssueInvoice(testIssueRequest("R001"))
+	if !ecpay.IsError(err, ecpay.ErrCodeResponse) {
+		t.Fatalf("IssueInvoice err = %v, want response error", err)
+	}
+	
+	var e *ecpay.Error
+	if !errors.As(err, &e) || e.Attempts != 3 {
+		t.Errorf("err = %v, want 3 attempts", err)
+	}
+	if calls := server.Calls("/B2CInvoice/Issue"); calls != 1 {
+		t.Errorf("Issue calls = %d, want 1", calls)
+	}
+	if calls := server.Calls("/B2CInvoice/GetIssue"); calls != 2 {
+		t.Errorf("GetIssue calls = %d, want 2", calls)
+	}
+}
+
+func TestIssueContextCanceled(t *testing.T) {
+	server, client := newRetryClient(t)
+	
+	// 已取消的 ctx 不送出請求
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.IssueInvoiceContext(ctx, testIssueRequest("R001"))
+	if !ecpay.IsError(err, ecpay.ErrCodeCanceled) {
+		t.Fatalf("IssueInvoiceContext err = %v, want canceled error", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("errors.Is(%v, context.Canceled) = false", err)
+	}
+	if calls := server.Calls("/B2CInvoice/Issue"); calls != 0 {
+		t.Errorf("Issue calls = %d, want 0", calls)
+	}
+}
+
+func TestIssueContextDeadline(t *testing.T) {
+	server, client := newRetryClient(t)
+	
+	// 期限在客戶端逾時之前到期，不再重試
+	server.InjectFault("/B2CInvoice/Issue", 0, ecpaytest.Fault{Kind: ecpaytest.FaultDelay, Delay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.IssueInvoiceContext(ctx, testIssueRequest("R001"))
+	if !ecpay.IsError(err, ecpay.ErrCodeDeadline) {
+		t.Fatalf("IssueInvoiceContext err = %v, want deadline error", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("errors.Is(%v, context.DeadlineExceeded) = false", err)
+	}
+	if calls := server.Calls("/B2CInvoice/Issue"); calls != 1 {
+		t.Errorf("Issue calls = %d, want 1", calls)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,127 @@
+package ecpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName OpenTelemetry instrumentation 名稱
+const tracerName = "github.com/YiChien-everlink/ecpay-invoice-sdk"
+
+// 追蹤屬性
+const (
+	attrAPI          = attribute.Key("ecpay.api")
+	attrMerchantID   = attribute.Key("ecpay.merchant_id")
+	attrRelateNumber = attribute.Key("ecpay.relate_number")
+	attrInvoiceNo    = attribute.Key("ecpay.invoice_no")
+	attrTransCode    = attribute.Key("ecpay.trans_code")
+	attrRtnCode      = attribute.Key("ecpay.rtn_code")
+	attrErrorCode    = attribute.Key("ecpay.error_code")
+	attrAttempts     = attribute.Key("ecpay.attempts")
+	attrReconciled   = attribute.Key("ecpay.reconciled")
+)
+
+// noopTracer 未啟用追蹤時使用
+var noopTracer = noop.NewTracerProvider().Tracer(tracerName)
+
+// WithTracerProvider 啟用 OpenTelemetry 追蹤
+// 每個公開方法建立一個 span（如 "ECPay IssueInvoice"），涵蓋請求驗證、前置查詢與重試；
+// 方法內的每次 API 呼叫為其子 client span（如 "ECPay /B2CInvoice/Issue"），加密、HTTP 往返與解密各為 client span 的子 span
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) error {
+		if tp == nil {
+			return NewError(ErrCodeValidation, "TracerProvider 不能為 nil")
+		}
+		c.tracer = tp.Tracer(tracerName)
+		return nil
+	}
+}
+
+// startMethod 建立公開方法的 span
+func (c *Client) startMethod(ctx context.Context, method string, req interface{}) (context.Context, trace.Span) {
+	ctx, span := c.tracer.Start(ctx, "ECPay "+method)
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	
+	span.SetAttributes(attrMerchantID.String(c.MerchantID))
+	setRequestIDs(span, req)
+	return ctx, span
+}
+
+// endMethod 記錄公開方法的錯誤並結束 span
+func endMethod(span trace.Span, err error) {
+	recordError(span, err)
+	span.End()
+}
+
+// startSpan 建立 API 呼叫的 span
+func (c *Client) startSpan(ctx context.Context, apiPath string, data interface{}) (context.Context, trace.Span) {
+	ctx, span := c.tracer.Start(ctx, "ECPay "+apiPath, trace.WithSpanKind(trace.SpanKindClient))
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	
+	span.SetAttributes(attrAPI.String(apiPath), attrMerchantID.String(c.MerchantID))
+	setRequestIDs(span, data)
+	return ctx, span
+}
+
+// setRequestIDs 在 span 上記錄請求的 RelateNumber 與 InvoiceNo
+func setRequestIDs(span trace.Span, data interface{}) {
+	relateNumber, invoiceNo := requestIDs(data)
+	if relateNumber != "" {
+		span.SetAttributes(attrRelateNumber.String(relateNumber))
+	}
+	if invoiceNo != "" {
+		span.SetAttributes(attrInvoiceNo.String(invoiceNo))
+	}
+}
+
+// endSpan 記錄 API 呼叫結果並結束 span
+func endSpan(span trace.Span, respData []byte, err error) {
+	defer span.End()
+	if !span.IsRecording() {
+		return
+	}
+	
+	// RtnCode 是否為錯誤由呼叫的方法判斷（例如查無資料可能是預期結果），這裡只記錄代碼
+	if respData != nil {
+		if code, ok := rtnCode(respData); ok {
+			span.SetAttributes(attrRtnCode.Int(code))
+		}
+	}
+	
+	recordError(span, err)
+}
+
+// recordError 在 span 上記錄錯誤
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	
+	var e *Error
+	if errors.As(err, &e) {
+		span.SetAttributes(attrErrorCode.String(string(e.Code)))
+		if e.Attempts > 0 {
+			span.SetAttributes(attrAttempts.Int(e.Attempts))
+		}
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// httpSpanOptions HTTP 往返 span 的屬性
+func httpSpanOptions(req *http.Request) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", req.URL.String()),
+	)
+}
\ No newline at end of file
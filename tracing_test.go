@@ -0,0 +1,280 @@
+package ecpay_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	
+	ecpay "github.com/YiChien-everlink/ecpay-invoice-sdk"
+	"github.com/YiChien-everlink/ecpay-invoice-sdk/ecpaytest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// spanStub 已結束的 span
+type spanStub struct {
+	Name        string
+	SpanContext trace.SpanContext
+	Parent      trace.SpanContext
+	Attributes  []attribute.KeyValue
+	Status      codes.Code
+}
+
+// spanRecorder 將結束的 span 記在記憶體的 TracerProvider，測試不需引入 OpenTelemetry SDK
+type spanRecorder struct {
+	embedded.TracerProvider
+	
+	mu     sync.Mutex
+	nextID uint64
+	spans  []spanStub
+}
+
+// Tracer 實作 trace.TracerProvider
+func (r *spanRecorder) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{recorder: r}
+}
+
+// Spans 回傳已結束的 span
+func (r *spanRecorder) Spans() []spanStub {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]spanStub(nil), r.spans...)
+}
+
+// Reset 清除已記錄的 span
+func (r *spanRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+// recordingTracer 建立 recordingSpan 的 Tracer
+type recordingTracer struct {
+	embedded.Tracer
+	recorder *spanRecorder
+}
+
+// Start 實作 trace.Tracer
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	r := t.recorder
+	r.mu.Lock()
+	r.nextID++
+	id := r.nextID
+	r.mu.Unlock()
+	
+	config := trace.NewSpanStartConfig(opts...)
+	parent := trace.SpanContextFromContext(ctx)
+	traceID := parent.TraceID()
+	if !parent.IsValid() {
+		traceID = trace.TraceID{byte(id >> 8), byte(id)}
+	}
+	span := &recordingSpan{
+		recorder: r,
+		stub: spanStub{
+			Name: name,
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    traceID,
+				SpanID:     trace.SpanID{byte(id >> 8), byte(id)},
+				TraceFlags: trace.FlagsSampled,
+			}),
+			Parent:     parent,
+			Attributes: config.Attributes(),
+		},
+	}
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// recordingSpan 記錄屬性與狀態的 span，其餘方法沿用 noop.Span
+type recordingSpan struct {
+	noop.Span
+	recorder *spanRecorder
+	stub     spanStub
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.stub.SpanContext }
+
+func (s *recordingSpan) IsRecording() bool { return true }
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.stub.Attributes = append(s.stub.Attributes, kv...)
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, description string) {
+	s.stub.Status = code
+}
+
+func (s *recordingSpan) TracerProvider() trace.TracerProvider { return s.recorder }
+
+func (s *recordingSpan) End(options ...trace.SpanEndOption) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.spans = append(s.recorder.spans, s.stub)
+}
+
+// newTracedClient 建立將 span 寫入記憶體的模擬伺服器與客戶端
+func newTracedClient(t *testing.T, opts ...ecpay.Option) (*ecpaytest.Server, *ecpay.Client, *spanRecorder) {
+	t.Helper()
+	
+	recorder := &spanRecorder{}
+	server, client := newTestClient(t, append(opts, ecpay.WithTracerProvider(recorder))...)
+	return server, client, recorder
+}
+
+// spansNamed 依名稱取得 span
+func spansNamed(spans []spanStub, name string) []spanStub {
+	var found []spanStub
+	for _, span := range spans {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	return found
+}
+
+// spanAttr 取得 span 的屬性值
+func spanAttr(span spanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingIssueInvoice(t *testing.T) {
+	_, client, recorder := newTracedClient(t)
+	
+	resp, err := client.IssueInvoice(testIssueRequest("R001"))
+	if err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	spans := recorder.Spans()
+	
+	// 方法 span 包住 API 呼叫的 client span
+	methods := spansNamed(spans, "ECPay IssueInvoice")
+	if len(methods) != 1 {
+		t.Fatalf("method spans = %d, want 1", len(methods))
+	}
+	method := methods[0]
+	if v, _ := spanAttr(method, "ecpay.relate_number"); v.AsString() != "R001" {
+		t.Errorf("relate_number = %q, want R001", v.AsString())
+	}
+	if v, _ := spanAttr(method, "ecpay.invoice_no"); v.AsString() != resp.InvoiceNo {
+		t.Errorf("invoice_no = %q, want %s", v.AsString(), resp.InvoiceNo)
+	}
+	
+	calls := spansNamed(spans, "ECPay /B2CInvoice/Issue")
+	if len(calls) != 1 {
+		t.Fatalf("client spans = %d, want 1", len(calls))
+	}
+	call := calls[0]
+	if call.Parent.SpanID() != method.SpanContext.SpanID() {
+		t.Error("client span is not a child of the method span")
+	}
+	if v, _ := spanAttr(call, "ecpay.relate_number"); v.AsString() != "R001" {
+		t.Errorf("client span relate_number = %q, want R001", v.AsString())
+	}
+	
+	// 加密、HTTP 往返與解密為 client span 的子 span
+	for _, name := range []string{"ecpay.encrypt", "ecpay.http", "ecpay.decrypt"} {
+		children := spansNamed(spans, name)
+		if len(children) != 1 || children[0].Parent.SpanID() != call.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of the client span", name)
+		}
+	}
+}
+
+func TestTracingError(t *testing.T) {
+	server, client, recorder := newTracedClient(t)
+	
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{Kind: ecpaytest.FaultTruncate})
+	if _, err := client.IssueInvoice(testIssueRequest("R001")); !ecpay.IsError(err, ecpay.ErrCodeResponse) {
+		t.Fatalf("IssueInvoice err = %v, want response error", err)
+	}
+	
+	for _, name := range []string{"ECPay IssueInvoice", "ECPay /B2CInvoice/Issue"} {
+		spans := spansNamed(recorder.Spans(), name)
+		if len(spans) != 1 {
+			t.Fatalf("%s spans = %d, want 1", name, len(spans))
+		}
+		if spans[0].Status != codes.Error {
+			t.Errorf("%s status = %v, want error", name, spans[0].Status)
+		}
+		if v, _ := spanAttr(spans[0], "ecpay.error_code"); v.AsString() != string(ecpay.ErrCodeResponse) {
+			t.Errorf("%s error_code = %q, want %s", name, v.AsString(), ecpay.ErrCodeResponse)
+		}
+	}
+}
+
+func TestTracingReconcileNotFound(t *testing.T) {
+	server, client, recorder := newTracedClient(t, ecpay.WithTimeout(200*time.Millisecond))
+	client.SetRetryPolicy(&ecpay.RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond})
+	
+	// 重送前查無發票是預期結果，查詢的 span 不應標示為錯誤
+	server.InjectFault("/B2CInvoice/Issue", 1, ecpaytest.Fault{Kind: ecpaytest.FaultDelay, Delay: time.Second})
+	if _, err := client.IssueInvoice(testIssueRequest("R001")); err != nil {
+		t.Fatalf("IssueInvoice: %v", err)
+	}
+	
+	lookups := spansNamed(recorder.Spans(), "ECPay /B2CInvoice/GetIssue")
+	if len(lookups) != 1 {
+		t.Fatalf("GetIssue spans = %d, want 1", len(lookups))
+	}
+	if v, _ := spanAttr(lookups[0], "ecpay.rtn_code"); v.AsInt64() != ecpaytest.RtnCodeNotFound {
+		t.Errorf("rtn_code = %d, want %d", v.AsInt64(), ecpaytest.RtnCodeNotFound)
+	}
+	if lookups[0].Status == codes.Error {
+		t.Error("GetIssue span status = error, want unset")
+	}
+	if _, ok := spanAttr(lookups[0], "ecpay.error_code"); ok {
+		t.Error("GetIssue span has an error_code")
+	}
+	
+	methods := spansNamed(recorder.Spans(), "ECPay IssueInvoice")
+	if len(methods) != 1 || methods[0].Status == codes.Error {
+		t.Errorf("IssueInvoice spans = %+v, want one without error", methods)
+	}
+}
+
+func TestTracingListInvoices(t *testing.T) {
+	_, client, recorder := newTracedClient(t)
+	
+	for _, relateNumber := range []string{"R001", "R002", "R003"} {
+		if _, err := client.IssueInvoice(testIssueRequest(relateNumber)); err != nil {
+			t.Fatalf("IssueInvoice: %v", err)
+		}
+	}
+	recorder.Reset()
+	
+	// 逐頁查詢都在同一個方法 span 之下
+	now := time.Now()
+	count := 0
+	for _, err := range client.ListInvoices(context.Background(), &ecpay.InvoiceListFilter{BeginDate: now, EndDate: now, PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("ListInvoices: %v", err)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("invoices = %d, want 3", count)
+	}
+	
+	spans := recorder.Spans()
+	methods := spansNamed(spans, "ECPay ListInvoices")
+	if len(methods) != 1 {
+		t.Fatalf("method spans = %d, want 1", len(methods))
+	}
+	pages := spansNamed(spans, "ECPay /B2CInvoice/GetIssueList")
+	if len(pages) != 2 {
+		t.Fatalf("page spans = %d, want 2", len(pages))
+	}
+	for _, page := range pages {
+		if page.Parent.SpanID() != methods[0].SpanContext.SpanID() {
+			t.Error("page span is not a child of the method span")
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,485 @@
+package ecpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// InvoicePeriod 發票期別，每兩個月為一期
+type InvoicePeriod struct {
+	Year int // 民國年
+	Term int // 期別 1: 1-2 月, 2: 3-4 月 ... 6: 11-12 月
+}
+
+// PeriodOf 取得時間所屬的發票期別
+func PeriodOf(t time.Time) InvoicePeriod {
+	return InvoicePeriod{
+		Year: t.Year() - 1911,
+		Term: (int(t.Month())-1)/2 + 1,
+	}
+}
+
+// Next 下一期
+func (p InvoicePeriod) Next() InvoicePeriod {
+	if p.Term >= 6 {
+		return InvoicePeriod{Year: p.Year + 1, Term: 1}
+	}
+	return InvoicePeriod{Year: p.Year, Term: p.Term + 1}
+}
+
+// Validate 驗證期別
+func (p InvoicePeriod) Validate() error {
+	if p.Year <= 0 {
+		return NewError(ErrCodeValidation, "發票年度不正確")
+	}
+	
+	if p.Term < 1 || p.Term > 6 {
+		return NewError(ErrCodeValidation, "發票期別必須介於 1~6")
+	}
+	
+	return nil
+}
+
+// String 格式化期別，例如 113年01-02月
+func (p InvoicePeriod) String() string {
+	return fmt.Sprintf("%d年%02d-%02d月", p.Year, p.Term*2-1, p.Term*2)
+}
+
+// year 綠界 InvoiceYear 欄位格式
+func (p InvoicePeriod) year() string {
+	return strconv.Itoa(p.Year)
+}
+
+// GovInvoiceWord 財政部配號結果
+type GovInvoiceWord struct {
+	Period        InvoicePeriod
+	InvType       string // InvTypeGeneral / InvTypeSpecial
+	InvoiceHeader string // 字軌英文
+	InvoiceStart  string
+	InvoiceEnd    string
+	Number        int // 本數
+}
+
+// govInvoiceWordData 查詢財政部配號結果回應資料
+type govInvoiceWordData struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	InvoiceInfo []struct {
+		InvoiceTerm   flexNumber `json:"InvoiceTerm"`
+		InvType       string     `json:"InvType"`
+		InvoiceHeader string     `json:"InvoiceHeader"`
+		InvoiceStart  string     `json:"InvoiceStart"`
+		InvoiceEnd    string     `json:"InvoiceEnd"`
+		Number        flexNumber `json:"Number"`
+	} `json:"InvoiceInfo"`
+}
+
+// GetGovInvoiceWordSettingRequest 查詢財政部配號結果請求
+type GetGovInvoiceWordSettingRequest struct {
+	Year int `json:"InvoiceYear"` // 民國年
+}
+
+// Validate 驗證查詢財政部配號結果請求
+func (r *GetGovInvoiceWordSettingRequest) Validate() error {
+	if r.Year <= 0 {
+		return NewError(ErrCodeValidation, "發票年度不正確")
+	}
+	
+	return nil
+}
+
+// GetGovInvoiceWordSettingResponse 查詢財政部配號結果回應
+type GetGovInvoiceWordSettingResponse struct {
+	RtnCode     int              `json:"RtnCode"`
+	RtnMsg      string           `json:"RtnMsg"`
+	InvoiceInfo []GovInvoiceWord `json:"InvoiceInfo"`
+}
+
+// getGovInvoiceWordSettingRequest 查詢財政部配號結果 API 請求格式
+type getGovInvoiceWordSettingRequest struct {
+	InvoiceYear string `json:"InvoiceYear"`
+}
+
+// GetGovInvoiceWordSetting 查詢財政部配號結果
+func (c *Client) GetGovInvoiceWordSetting(req *GetGovInvoiceWordSettingRequest) (*GetGovInvoiceWordSettingResponse, error) {
+	return c.GetGovInvoiceWordSettingContext(context.Background(), req)
+}
+
+// GetGovInvoiceWordSettingContext 查詢財政部配號結果（可透過 ctx 取消或設定期限）
+func (c *Client) GetGovInvoiceWordSettingContext(ctx context.Context, req *GetGovInvoiceWordSettingRequest) (_ *GetGovInvoiceWordSettingResponse, err error) {
+	ctx, span := c.startMethod(ctx, "GetGovInvoiceWordSetting", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetGovInvoiceWordSetting", &getGovInvoiceWordSettingRequest{
+		InvoiceYear: strconv.Itoa(req.Year),
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var data govInvoiceWordData
+	if err := json.Unmarshal(respData, &data); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析財政部配號結果失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if data.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, data.RtnMsg)
+	}
+	
+	resp := &GetGovInvoiceWordSettingResponse{
+		RtnCode:     data.RtnCode,
+		RtnMsg:      data.RtnMsg,
+		InvoiceInfo: make([]GovInvoiceWord, 0, len(data.InvoiceInfo)),
+	}
+	for _, info := range data.InvoiceInfo {
+		resp.InvoiceInfo = append(resp.InvoiceInfo, GovInvoiceWord{
+			Period:        InvoicePeriod{Year: req.Year, Term: info.InvoiceTerm.Int()},
+			InvType:       info.InvType,
+			InvoiceHeader: info.InvoiceHeader,
+			InvoiceStart:  info.InvoiceStart,
+			InvoiceEnd:    info.InvoiceEnd,
+			Number:        info.Number.Int(),
+		})
+	}
+	
+	return resp, nil
+}
+
+// AddInvoiceWordRequest 字軌與配號設定請求
+type AddInvoiceWordRequest struct {
+	Period          InvoicePeriod
+	InvType         string // InvTypeGeneral / InvTypeSpecial
+	InvoiceCategory int    // InvoiceCategoryB2C / InvoiceCategoryB2B，預設 B2C
+	InvoiceHeader   string // 字軌英文，2 碼大寫
+	InvoiceStart    string // 起始號碼，末兩碼須為 00 或 50
+	InvoiceEnd      string // 結束號碼，末兩碼須為 49 或 99
+}
+
+// Validate 驗證字軌與配號設定請求
+func (r *AddInvoiceWordRequest) Validate() error {
+	if err := r.Period.Validate(); err != nil {
+		return err
+	}
+	
+	if r.InvType != InvTypeGeneral && r.InvType != InvTypeSpecial {
+		return NewError(ErrCodeValidation, "InvType 不正確")
+	}
+	
+	switch r.InvoiceCategory {
+	case 0, InvoiceCategoryB2C, InvoiceCategoryB2B:
+	default:
+		return NewError(ErrCodeValidation, "InvoiceCategory 不正確")
+	}
+	
+	if !invoiceHeaderRegex.MatchString(r.InvoiceHeader) {
+		return NewError(ErrCodeValidation, "InvoiceHeader 必須為 2 碼大寫英文")
+	}
+	
+	return validateInvoiceRange(r.InvoiceStart, r.InvoiceEnd)
+}
+
+// validateInvoiceRange 驗證發票號碼區間，每本 50 張
+func validateInvoiceRange(start, end string) error {
+	if !invoiceNumberRegex.MatchString(start) || !invoiceNumberRegex.MatchString(end) {
+		return NewError(ErrCodeValidation, "發票號碼必須為 8 碼數字")
+	}
+	
+	startNum, _ := strconv.Atoi(start)
+	endNum, _ := strconv.Atoi(end)
+	if startNum%50 != 0 || endNum%50 != 49 {
+		return NewError(ErrCodeValidation, "發票號碼區間必須以 50 張為單位")
+	}
+	
+	if endNum < startNum {
+		return NewError(ErrCodeValidation, "結束號碼不能小於起始號碼")
+	}
+	
+	return nil
+}
+
+// addInvoiceWordRequest 字軌與配號設定 API 請求格式
+type addInvoiceWordRequest struct {
+	InvoiceTerm     int    `json:"InvoiceTerm"`
+	InvoiceYear     string `json:"InvoiceYear"`
+	InvType         string `json:"InvType"`
+	InvoiceCategory int    `json:"InvoiceCategory"`
+	InvoiceHeader   string `json:"InvoiceHeader"`
+	InvoiceStart    string `json:"InvoiceStart"`
+	InvoiceEnd      string `json:"InvoiceEnd"`
+}
+
+// AddInvoiceWordResponse 字軌與配號設定回應
+type AddInvoiceWordResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+	TrackID string `json:"TrackID"` // 字軌號碼 ID
+}
+
+// AddInvoiceWordSetting 字軌與配號設定
+// 新增的字軌預設為未啟用，需再以 UpdateInvoiceWordStatus 啟用
+func (c *Client) AddInvoiceWordSetting(req *AddInvoiceWordRequest) (*AddInvoiceWordResponse, error) {
+	return c.AddInvoiceWordSettingContext(context.Background(), req)
+}
+
+// AddInvoiceWordSettingContext 字軌與配號設定（可透過 ctx 取消或設定期限）
+func (c *Client) AddInvoiceWordSettingContext(ctx context.Context, req *AddInvoiceWordRequest) (_ *AddInvoiceWordResponse, err error) {
+	ctx, span := c.startMethod(ctx, "AddInvoiceWordSetting", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	apiReq := addInvoiceWordRequest{
+		InvoiceTerm:     req.Period.Term,
+		InvoiceYear:     req.Period.year(),
+		InvType:         req.InvType,
+		InvoiceCategory: req.InvoiceCategory,
+		InvoiceHeader:   req.InvoiceHeader,
+		InvoiceStart:    req.InvoiceStart,
+		InvoiceEnd:      req.InvoiceEnd,
+	}
+	if apiReq.InvoiceCategory == 0 {
+		apiReq.InvoiceCategory = InvoiceCategoryB2C
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/AddInvoiceWordSetting", &apiReq)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp AddInvoiceWordResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析字軌設定回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// UpdateInvoiceWordStatusRequest 設定字軌號碼狀態請求
+type UpdateInvoiceWordStatusRequest struct {
+	TrackID       string            `json:"TrackID"`
+	InvoiceStatus InvoiceWordStatus `json:"InvoiceStatus"` // WordStatusDisable / WordStatusPause / WordStatusEnable
+}
+
+// Validate 驗證設定字軌號碼狀態請求
+func (r *UpdateInvoiceWordStatusRequest) Validate() error {
+	if r.TrackID == "" {
+		return NewError(ErrCodeValidation, "TrackID 不能為空")
+	}
+	
+	switch r.InvoiceStatus {
+	case WordStatusDisable, WordStatusPause, WordStatusEnable:
+	default:
+		return NewError(ErrCodeValidation, "字軌狀態不正確")
+	}
+	
+	return nil
+}
+
+// UpdateInvoiceWordStatusResponse 設定字軌號碼狀態回應
+type UpdateInvoiceWordStatusResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+}
+
+// UpdateInvoiceWordStatus 設定字軌號碼狀態
+func (c *Client) UpdateInvoiceWordStatus(req *UpdateInvoiceWordStatusRequest) (*UpdateInvoiceWordStatusResponse, error) {
+	return c.UpdateInvoiceWordStatusContext(context.Background(), req)
+}
+
+// UpdateInvoiceWordStatusContext 設定字軌號碼狀態（可透過 ctx 取消或設定期限）
+func (c *Client) UpdateInvoiceWordStatusContext(ctx context.Context, req *UpdateInvoiceWordStatusRequest) (_ *UpdateInvoiceWordStatusResponse, err error) {
+	ctx, span := c.startMethod(ctx, "UpdateInvoiceWordStatus", req)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/UpdateInvoiceWordStatus", req)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp UpdateInvoiceWordStatusResponse
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析字軌狀態回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	return &resp, nil
+}
+
+// InvoiceWordFilter 查詢字軌條件
+type InvoiceWordFilter struct {
+	Period          InvoicePeriod // Term 為 0 時查詢全年度
+	UseStatus       int           // WordUseStatus*，預設全部
+	InvoiceCategory int           // InvoiceCategoryB2C / InvoiceCategoryB2B，預設 B2C
+	InvType         string        // InvTypeGeneral / InvTypeSpecial，空字串表示不限
+	InvoiceHeader   string        // 字軌英文，空字串表示不限
+}
+
+// Validate 驗證查詢字軌條件
+func (f *InvoiceWordFilter) Validate() error {
+	if f.Period.Year <= 0 {
+		return NewError(ErrCodeValidation, "發票年度不正確")
+	}
+	
+	if f.Period.Term < 0 || f.Period.Term > 6 {
+		return NewError(ErrCodeValidation, "發票期別必須介於 0~6")
+	}
+	
+	if f.UseStatus < WordUseStatusAll || f.UseStatus > WordUseStatusRejected {
+		return NewError(ErrCodeValidation, "UseStatus 不正確")
+	}
+	
+	switch f.InvoiceCategory {
+	case 0, InvoiceCategoryB2C, InvoiceCategoryB2B:
+	default:
+		return NewError(ErrCodeValidation, "InvoiceCategory 不正確")
+	}
+	
+	switch f.InvType {
+	case "", InvTypeGeneral, InvTypeSpecial:
+	default:
+		return NewError(ErrCodeValidation, "InvType 不正確")
+	}
+	
+	if f.InvoiceHeader != "" && !invoiceHeaderRegex.MatchString(f.InvoiceHeader) {
+		return NewError(ErrCodeValidation, "InvoiceHeader 必須為 2 碼大寫英文")
+	}
+	
+	return nil
+}
+
+// getInvoiceWordRequest 查詢字軌 API 請求格式
+type getInvoiceWordRequest struct {
+	InvoiceYear     string `json:"InvoiceYear"`
+	InvoiceTerm     int    `json:"InvoiceTerm"`
+	UseStatus       int    `json:"UseStatus"`
+	InvoiceCategory int    `json:"InvoiceCategory"`
+	InvType         string `json:"InvType,omitempty"`
+	InvoiceHeader   string `json:"InvoiceHeader,omitempty"`
+}
+
+// InvoiceWord 字軌設定
+type InvoiceWord struct {
+	TrackID       string
+	Period        InvoicePeriod
+	InvType       string
+	InvoiceHeader string
+	InvoiceStart  string
+	InvoiceEnd    string
+	UseStatus     int // WordUseStatus*
+}
+
+// invoiceWordData 查詢字軌回應資料
+type invoiceWordData struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	InvoiceInfo []struct {
+		TrackID       string     `json:"TrackID"`
+		InvoiceYear   flexNumber `json:"InvoiceYear"`
+		InvoiceTerm   flexNumber `json:"InvoiceTerm"`
+		InvType       string     `json:"InvType"`
+		InvoiceHeader string     `json:"InvoiceHeader"`
+		InvoiceStart  string     `json:"InvoiceStart"`
+		InvoiceEnd    string     `json:"InvoiceEnd"`
+		UseStatus     flexNumber `json:"UseStatus"`
+	} `json:"InvoiceInfo"`
+}
+
+// GetInvoiceWordSetting 查詢字軌
+func (c *Client) GetInvoiceWordSetting(filter *InvoiceWordFilter) ([]InvoiceWord, error) {
+	return c.GetInvoiceWordSettingContext(context.Background(), filter)
+}
+
+// GetInvoiceWordSettingContext 查詢字軌（可透過 ctx 取消或設定期限）
+func (c *Client) GetInvoiceWordSettingContext(ctx context.Context, filter *InvoiceWordFilter) (_ []InvoiceWord, err error) {
+	ctx, span := c.startMethod(ctx, "GetInvoiceWordSetting", nil)
+	defer func() {
+		endMethod(span, err)
+	}()
+	
+	// 驗證請求
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	
+	apiReq := getInvoiceWordRequest{
+		InvoiceYear:     filter.Period.year(),
+		InvoiceTerm:     filter.Period.Term,
+		UseStatus:       filter.UseStatus,
+		InvoiceCategory: filter.InvoiceCategory,
+		InvType:         filter.InvType,
+		InvoiceHeader:   filter.InvoiceHeader,
+	}
+	if apiReq.InvoiceCategory == 0 {
+		apiReq.InvoiceCategory = InvoiceCategoryB2C
+	}
+	
+	// 發送請求
+	respData, err := c.sendRequest(ctx, "/B2CInvoice/GetInvoiceWordSetting", &apiReq)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析回應
+	var resp invoiceWordData
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, NewError(ErrCodeParse, fmt.Sprintf("解析字軌回應失敗: %v", err))
+	}
+	
+	// 檢查業務邏輯錯誤
+	if resp.RtnCode != 1 {
+		return nil, NewError(ErrCodeAPI, resp.RtnMsg)
+	}
+	
+	words := make([]InvoiceWord, 0, len(resp.InvoiceInfo))
+	for _, info := range resp.InvoiceInfo {
+		words = append(words, InvoiceWord{
+			TrackID:       info.TrackID,
+			Period:        InvoicePeriod{Year: info.InvoiceYear.Int(), Term: info.InvoiceTerm.Int()},
+			InvType:       info.InvType,
+			InvoiceHeader: info.InvoiceHeader,
+			InvoiceStart:  info.InvoiceStart,
+			InvoiceEnd:    info.InvoiceEnd,
+			UseStatus:     info.UseStatus.Int(),
+		})
+	}
+	
+	return words, nil
+}
\ No newline at end of file
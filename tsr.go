@@ -0,0 +1,60 @@
+package ecpay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// TsrStore 記錄延遲開立已使用的交易單號（Tsr），DelayIssue 送出前以此檢查 Tsr 不可重複
+// 多個程序共用同一商店代號時，應以共用的儲存實作（例如資料庫唯一索引）
+type TsrStore interface {
+	// Reserve 保留 tsr，已使用過時回傳 false
+	Reserve(ctx context.Context, tsr string) (bool, error)
+	// Release 釋放綠界未接受的 tsr，使其可再次使用
+	Release(ctx context.Context, tsr string) error
+}
+
+// MemoryTsrStore 以記憶體保存已使用的 Tsr，只在單一程序內有效
+type MemoryTsrStore struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+// NewMemoryTsrStore 建立記憶體 Tsr 儲存
+func NewMemoryTsrStore() *MemoryTsrStore {
+	return &MemoryTsrStore{used: make(map[string]bool)}
+}
+
+// Reserve 保留 tsr
+func (s *MemoryTsrStore) Reserve(ctx context.Context, tsr string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.used[tsr] {
+		return false, nil
+	}
+	s.used[tsr] = true
+	return true, nil
+}
+
+// Release 釋放 tsr
+func (s *MemoryTsrStore) Release(ctx context.Context, tsr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	delete(s.used, tsr)
+	return nil
+}
+
+// reserveTsr 保留延遲開立的交易單號，已使用過時回傳 ErrCodeValidation
+func (c *Client) reserveTsr(ctx context.Context, tsr string) error {
+	ok, err := c.tsrStore.Reserve(ctx, tsr)
+	if err != nil {
+		return &Error{Code: ErrCodeRequest, Message: fmt.Sprintf("保留 Tsr 失敗: %v", err), Err: err}
+	}
+	if !ok {
+		return NewError(ErrCodeValidation, "Tsr 已使用過，不可重複")
+	}
+	return nil
+}
\ No newline at end of file
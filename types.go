@@ -1,14 +1,41 @@
 package ecpay
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
+	"time"
+)
+
+var (
+	emailRegex         = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	phoneRegex         = regexp.MustCompile(`^09\d{8}$`)
+	invoiceNoRegex     = regexp.MustCompile(`^[A-Z]{2}\d{8}$`)
+	allowanceNoRegex   = regexp.MustCompile(`^\d{16}$`)
+	tsrRegex           = regexp.MustCompile(`^[a-zA-Z0-9]{1,30}$`)
+	barcodeRegex       = regexp.MustCompile(`^/[0-9A-Z.+\-]{7}$`)
+	randomNumberRegex  = regexp.MustCompile(`^\d{4}$`)
+	invoiceHeaderRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+	invoiceNumberRegex = regexp.MustCompile(`^\d{8}$`)
 )
 
 // Environment 環境設定
 type Environment string
 
+// InvoiceTag 通知發送類別
+type InvoiceTag string
+
+// NotifyChannel 通知方式
+type NotifyChannel string
+
+// NotifyTarget 通知對象
+type NotifyTarget string
+
+// InvoiceWordStatus 字軌號碼狀態設定
+type InvoiceWordStatus int
+
 // BaseRequest 基本請求結構
 type BaseRequest struct {
 	MerchantID string `json:"MerchantID"`
@@ -87,7 +114,6 @@ func (r *IssueInvoiceRequest) Validate() error {
 	
 	// 驗證 Email 格式
 	if r.CustomerEmail != "" {
-		emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 		if !emailRegex.MatchString(r.CustomerEmail) {
 			return NewError(ErrCodeValidation, "Email 格式不正確")
 		}
@@ -95,7 +121,6 @@ func (r *IssueInvoiceRequest) Validate() error {
 	
 	// 驗證手機號碼格式（台灣手機）
 	if r.CustomerPhone != "" {
-		phoneRegex := regexp.MustCompile(`^09\d{8}$`)
 		if !phoneRegex.MatchString(r.CustomerPhone) {
 			return NewError(ErrCodeValidation, "手機號碼格式不正確")
 		}
@@ -118,27 +143,30 @@ func (r *IssueInvoiceRequest) Validate() error {
 		return NewError(ErrCodeValidation, "選擇載具類別時必須填寫載具編號")
 	}
 	
-	// 驗證商品明細
-	if len(r.Items) == 0 {
+	salesAmount, err := strconv.Atoi(r.SalesAmount)
+	if err != nil {
+		return NewError(ErrCodeValidation, "發票金額格式錯誤")
+	}
+	
+	// 驗證商品明細與金額
+	// B2C API 中 SalesAmount 是未稅金額，稅額另計
+	return validateItems(r.Items, salesAmount, "發票金額")
+}
+
+// validateItems 驗證商品明細不為空，且明細金額合計與 amount 一致
+func validateItems(items []Item, amount int, label string) error {
+	if len(items) == 0 {
 		return NewError(ErrCodeValidation, "商品明細不能為空")
 	}
 	
-	// 驗證金額
 	totalAmount := 0
-	for _, item := range r.Items {
+	for _, item := range items {
 		totalAmount += item.ItemAmount
 	}
 	
-	salesAmount, err := strconv.Atoi(r.SalesAmount)
-	if err != nil {
-		return NewError(ErrCodeValidation, "發票金額格式錯誤")
-	}
-	
-	// 檢查金額是否一致
-	// B2C API 中 SalesAmount 是未稅金額，稅額另計
-	if totalAmount != salesAmount {
-		return NewError(ErrCodeValidation, 
-			fmt.Sprintf("發票金額不一致: 預期 %d, 實際 %d", totalAmount, salesAmount))
+	if totalAmount != amount {
+		return NewError(ErrCodeValidation,
+			fmt.Sprintf("%s不一致: 預期 %d, 實際 %d", label, totalAmount, amount))
 	}
 	
 	return nil
@@ -151,6 +179,9 @@ type IssueInvoiceResponse struct {
 	InvoiceNo    string `json:"InvoiceNo"`
 	InvoiceDate  string `json:"InvoiceDate"`
 	RandomNumber string `json:"RandomNumber"`
+	
+	// Reconciled 重試前查詢確認前次請求已開立，本次未重送
+	Reconciled bool `json:"-"`
 }
 
 // InvalidInvoiceRequest 作廢發票請求
@@ -170,11 +201,16 @@ func (r *InvalidInvoiceRequest) Validate() error {
 		return NewError(ErrCodeValidation, "InvoiceDate 不能為空")
 	}
 	
-	if r.Reason == "" {
+	return validateReason(r.Reason)
+}
+
+// validateReason 驗證作廢/取消原因，長度上限為 20 位元組（與 InvalidInvoiceRequest 原本的檢查相同）
+func validateReason(reason string) error {
+	if reason == "" {
 		return NewError(ErrCodeValidation, "Reason 不能為空")
 	}
 	
-	if len(r.Reason) > 20 {
+	if len(reason) > 20 {
 		return NewError(ErrCodeValidation, "Reason 長度不能超過 20")
 	}
 	
@@ -185,4 +221,671 @@ func (r *InvalidInvoiceRequest) Validate() error {
 type InvalidInvoiceResponse struct {
 	RtnCode int    `json:"RtnCode"`
 	RtnMsg  string `json:"RtnMsg"`
+}
+
+// AllowanceRequest 開立折讓請求
+type AllowanceRequest struct {
+	InvoiceNo       string `json:"InvoiceNo"`
+	InvoiceDate     string `json:"InvoiceDate"`     // 發票開立日期 yyyy-MM-dd
+	AllowanceNotify string `json:"AllowanceNotify"` // 通知類別
+	CustomerName    string `json:"CustomerName,omitempty"`
+	NotifyMail      string `json:"NotifyMail,omitempty"`
+	NotifyPhone     string `json:"NotifyPhone,omitempty"`
+	AllowanceAmount int    `json:"AllowanceAmount"` // 折讓含稅總金額
+	
+	// 商品明細
+	Items []Item `json:"Items"`
+	
+	// RemainingAmount 原發票剩餘可折讓金額（不送出），有設定時檢查折讓金額不得超過；
+	// 已全額折讓的發票請設為 0 而非 nil
+	RemainingAmount *int `json:"-"`
+}
+
+// Validate 驗證開立折讓請求
+func (r *AllowanceRequest) Validate() error {
+	if r.InvoiceNo == "" {
+		return NewError(ErrCodeValidation, "InvoiceNo 不能為空")
+	}
+	
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	if r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "InvoiceDate 不能為空")
+	}
+	
+	// 驗證通知方式
+	switch r.AllowanceNotify {
+	case AllowanceNotifySMS:
+		if r.NotifyPhone == "" {
+			return NewError(ErrCodeValidation, "簡訊通知時必須填寫 NotifyPhone")
+		}
+	case AllowanceNotifyEmail:
+		if r.NotifyMail == "" {
+			return NewError(ErrCodeValidation, "電子郵件通知時必須填寫 NotifyMail")
+		}
+	case AllowanceNotifyAll:
+		if r.NotifyPhone == "" || r.NotifyMail == "" {
+			return NewError(ErrCodeValidation, "皆通知時必須填寫 NotifyPhone 與 NotifyMail")
+		}
+	case AllowanceNotifyNone:
+	default:
+		return NewError(ErrCodeValidation, "AllowanceNotify 不正確")
+	}
+	
+	if r.NotifyMail != "" && !emailRegex.MatchString(r.NotifyMail) {
+		return NewError(ErrCodeValidation, "Email 格式不正確")
+	}
+	
+	if r.NotifyPhone != "" && !phoneRegex.MatchString(r.NotifyPhone) {
+		return NewError(ErrCodeValidation, "手機號碼格式不正確")
+	}
+	
+	if r.AllowanceAmount <= 0 {
+		return NewError(ErrCodeValidation, "折讓金額必須大於 0")
+	}
+	
+	if err := validateItems(r.Items, r.AllowanceAmount, "折讓金額"); err != nil {
+		return err
+	}
+	
+	// 檢查剩餘可折讓金額
+	if r.RemainingAmount != nil && r.AllowanceAmount > *r.RemainingAmount {
+		return NewError(ErrCodeValidation,
+			fmt.Sprintf("折讓金額 %d 超過剩餘可折讓金額 %d", r.AllowanceAmount, *r.RemainingAmount))
+	}
+	
+	return nil
+}
+
+// AllowanceResponse 開立折讓回應
+type AllowanceResponse struct {
+	RtnCode                 int    `json:"RtnCode"`
+	RtnMsg                  string `json:"RtnMsg"`
+	IAAllowNo               string `json:"IA_Allow_No"`             // 折讓單號
+	IAInvoiceNo             string `json:"IA_Invoice_No"`           // 發票號碼
+	IADate                  string `json:"IA_Date"`                 // 折讓日期
+	IARemainAllowanceAmount int    `json:"IA_Remain_Allowance_Amt"` // 剩餘可折讓金額
+}
+
+// AllowanceByCollegiateRequest 線上折讓請求
+// 綠界會以 Email 通知消費者，消費者同意或拒絕後 POST 結果至 ReturnURL
+type AllowanceByCollegiateRequest struct {
+	AllowanceRequest
+	ReturnURL string `json:"ReturnURL"` // 消費者同意結果通知網址
+}
+
+// Validate 驗證線上折讓請求
+func (r *AllowanceByCollegiateRequest) Validate() error {
+	if r.AllowanceNotify != AllowanceNotifyEmail {
+		return NewError(ErrCodeValidation, "線上折讓的 AllowanceNotify 必須為 E")
+	}
+	
+	if err := r.AllowanceRequest.Validate(); err != nil {
+		return err
+	}
+	
+	if r.ReturnURL == "" {
+		return NewError(ErrCodeValidation, "ReturnURL 不能為空")
+	}
+	
+	u, err := url.Parse(r.ReturnURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewError(ErrCodeValidation, "ReturnURL 格式不正確")
+	}
+	
+	if len(r.ReturnURL) > 200 {
+		return NewError(ErrCodeValidation, "ReturnURL 長度不能超過 200")
+	}
+	
+	return nil
+}
+
+// AllowanceConsentResult 線上折讓消費者同意結果
+type AllowanceConsentResult struct {
+	RtnCode         int // 1 為消費者同意
+	RtnMsg          string
+	Agreed          bool      // 消費者是否同意折讓
+	AllowanceNo     string    // 折讓單號
+	InvoiceNo       string    // 發票號碼
+	AllowanceDate   time.Time // 折讓日期
+	RemainingAmount int       // 剩餘可折讓金額
+}
+
+// allowanceConsentData 線上折讓通知解密後資料
+type allowanceConsentData struct {
+	RtnCode                 int    `json:"RtnCode"`
+	RtnMsg                  string `json:"RtnMsg"`
+	IAAllowNo               string `json:"IA_Allow_No"`
+	IAInvoiceNo             string `json:"IA_Invoice_No"`
+	IADate                  string `json:"IA_Date"`
+	IARemainAllowanceAmount int    `json:"IA_Remain_Allowance_Amt"`
+}
+
+// InvalidAllowanceRequest 作廢折讓請求
+type InvalidAllowanceRequest struct {
+	InvoiceNo   string `json:"InvoiceNo"`
+	AllowanceNo string `json:"AllowanceNo"`
+	Reason      string `json:"Reason"`
+}
+
+// Validate 驗證作廢折讓請求
+func (r *InvalidAllowanceRequest) Validate() error {
+	return validateAllowanceReversal(r.InvoiceNo, r.AllowanceNo, r.Reason)
+}
+
+// InvalidAllowanceResponse 作廢折讓回應
+type InvalidAllowanceResponse struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	IAInvoiceNo string `json:"IA_Invoice_No"`
+}
+
+// CancelAllowanceRequest 取消線上折讓請求
+type CancelAllowanceRequest struct {
+	InvoiceNo   string `json:"InvoiceNo"`
+	AllowanceNo string `json:"AllowanceNo"`
+	Reason      string `json:"Reason"`
+}
+
+// Validate 驗證取消線上折讓請求
+func (r *CancelAllowanceRequest) Validate() error {
+	return validateAllowanceReversal(r.InvoiceNo, r.AllowanceNo, r.Reason)
+}
+
+// CancelAllowanceResponse 取消線上折讓回應
+type CancelAllowanceResponse struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	IAInvoiceNo string `json:"IA_Invoice_No"`
+}
+
+// validateAllowanceReversal 驗證作廢/取消折讓共用欄位
+func validateAllowanceReversal(invoiceNo, allowanceNo, reason string) error {
+	if invoiceNo == "" {
+		return NewError(ErrCodeValidation, "InvoiceNo 不能為空")
+	}
+	
+	if !invoiceNoRegex.MatchString(invoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	if allowanceNo == "" {
+		return NewError(ErrCodeValidation, "AllowanceNo 不能為空")
+	}
+	
+	if !allowanceNoRegex.MatchString(allowanceNo) {
+		return NewError(ErrCodeValidation, "AllowanceNo 格式不正確")
+	}
+	
+	return validateReason(reason)
+}
+
+// DelayIssueRequest 延遲開立發票請求
+// 延遲天數使用內嵌 IssueInvoiceRequest 的 DelayDay，DelayFlag 為 1 時 1~15，為 2 時 0~15
+type DelayIssueRequest struct {
+	IssueInvoiceRequest
+	
+	// 延遲資訊
+	DelayFlag string `json:"DelayFlag"` // 延遲註記
+	Tsr       string `json:"Tsr"`       // 交易單號，不可重複，送出前以 TsrStore 檢查
+	PayType   string `json:"PayType"`   // 交易類別
+	PayAct    string `json:"PayAct"`    // 交易類別名稱
+	NotifyURL string `json:"NotifyURL,omitempty"`
+}
+
+// MarshalJSON 送出 DelayDay 時不省略 0（DelayFlag 為 2 時 0 為合法值）
+func (r DelayIssueRequest) MarshalJSON() ([]byte, error) {
+	type delayIssueRequest DelayIssueRequest
+	return json.Marshal(struct {
+		delayIssueRequest
+		DelayDay int `json:"DelayDay"`
+	}{delayIssueRequest(r), r.DelayDay})
+}
+
+// Validate 驗證延遲開立發票請求
+func (r *DelayIssueRequest) Validate() error {
+	if err := r.IssueInvoiceRequest.Validate(); err != nil {
+		return err
+	}
+	
+	switch r.DelayFlag {
+	case DelayFlagDelay:
+		if r.DelayDay < 1 || r.DelayDay > 15 {
+			return NewError(ErrCodeValidation, "DelayFlag 為 1 時 DelayDay 必須介於 1~15")
+		}
+	case DelayFlagTrigger:
+		if r.DelayDay < 0 || r.DelayDay > 15 {
+			return NewError(ErrCodeValidation, "DelayFlag 為 2 時 DelayDay 必須介於 0~15")
+		}
+	default:
+		return NewError(ErrCodeValidation, "DelayFlag 不正確")
+	}
+	
+	if err := validateTsr(r.Tsr); err != nil {
+		return err
+	}
+	
+	if r.PayType != PayTypeECPay {
+		return NewError(ErrCodeValidation, "PayType 不正確")
+	}
+	
+	if r.PayAct == "" {
+		return NewError(ErrCodeValidation, "PayAct 不能為空")
+	}
+	
+	return nil
+}
+
+// DelayIssueResponse 延遲開立發票回應
+type DelayIssueResponse struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	OrderNumber string `json:"OrderNumber"` // 交易單號
+}
+
+// TriggerIssueRequest 觸發開立發票請求
+type TriggerIssueRequest struct {
+	Tsr     string `json:"Tsr"`
+	PayType string `json:"PayType"`
+}
+
+// Validate 驗證觸發開立發票請求
+func (r *TriggerIssueRequest) Validate() error {
+	if err := validateTsr(r.Tsr); err != nil {
+		return err
+	}
+	
+	if r.PayType != PayTypeECPay {
+		return NewError(ErrCodeValidation, "PayType 不正確")
+	}
+	
+	return nil
+}
+
+// TriggerIssueResponse 觸發開立發票回應
+type TriggerIssueResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+}
+
+// CancelDelayIssueRequest 取消延遲開立發票請求
+type CancelDelayIssueRequest struct {
+	Tsr string `json:"Tsr"`
+}
+
+// Validate 驗證取消延遲開立發票請求
+func (r *CancelDelayIssueRequest) Validate() error {
+	return validateTsr(r.Tsr)
+}
+
+// CancelDelayIssueResponse 取消延遲開立發票回應
+type CancelDelayIssueResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+}
+
+// validateTsr 驗證交易單號格式，是否重複由 DelayIssue 送出前以 TsrStore 檢查
+func validateTsr(tsr string) error {
+	if tsr == "" {
+		return NewError(ErrCodeValidation, "Tsr 不能為空")
+	}
+	
+	if !tsrRegex.MatchString(tsr) {
+		return NewError(ErrCodeValidation, "Tsr 只能為英數字且長度不能超過 30")
+	}
+	
+	return nil
+}
+
+// GetIssueRequest 查詢發票請求
+// 以 RelateNumber 查詢，或以 InvoiceNo + InvoiceDate 查詢
+type GetIssueRequest struct {
+	RelateNumber string `json:"RelateNumber,omitempty"`
+	InvoiceNo    string `json:"InvoiceNo,omitempty"`
+	InvoiceDate  string `json:"InvoiceDate,omitempty"` // yyyy-MM-dd
+}
+
+// Validate 驗證查詢發票請求
+func (r *GetIssueRequest) Validate() error {
+	if r.RelateNumber != "" {
+		if len(r.RelateNumber) > 30 {
+			return NewError(ErrCodeValidation, "RelateNumber 長度不能超過 30")
+		}
+		return nil
+	}
+	
+	if r.InvoiceNo == "" || r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "必須提供 RelateNumber 或 InvoiceNo 與 InvoiceDate")
+	}
+	
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	return nil
+}
+
+// InvoiceListFilter 發票清單查詢條件
+type InvoiceListFilter struct {
+	BeginDate time.Time // 查詢起始日期（含）
+	EndDate   time.Time // 查詢結束日期（含）
+	PageSize  int       // 每頁筆數，預設 200，上限 200
+	
+	// 選填條件，空字串表示不限
+	InvalidStatus string // InvoiceStatusNormal / InvoiceStatusInvalid
+	UploadStatus  string // UploadStatusYes / UploadStatusNo
+	CarrierType   string // 載具類別，於 SDK 端過濾；CarrierTypeNone 表示不限
+}
+
+// Validate 驗證發票清單查詢條件
+func (f *InvoiceListFilter) Validate() error {
+	if f.BeginDate.IsZero() || f.EndDate.IsZero() {
+		return NewError(ErrCodeValidation, "BeginDate 與 EndDate 不能為空")
+	}
+	
+	if f.EndDate.Before(f.BeginDate) {
+		return NewError(ErrCodeValidation, "EndDate 不能早於 BeginDate")
+	}
+	
+	if f.PageSize < 0 || f.PageSize > 200 {
+		return NewError(ErrCodeValidation, "PageSize 必須介於 0~200（0 表示預設）")
+	}
+	
+	switch f.InvalidStatus {
+	case "", InvoiceStatusNormal, InvoiceStatusInvalid:
+	default:
+		return NewError(ErrCodeValidation, "InvalidStatus 不正確")
+	}
+	
+	switch f.UploadStatus {
+	case "", UploadStatusYes, UploadStatusNo:
+	default:
+		return NewError(ErrCodeValidation, "UploadStatus 不正確")
+	}
+	
+	return nil
+}
+
+// getIssueListRequest 查詢發票清單請求
+type getIssueListRequest struct {
+	BeginDate    string `json:"BeginDate"`
+	EndDate      string `json:"EndDate"`
+	NumPerPage   int    `json:"NumPerPage"`
+	ShowingPage  int    `json:"ShowingPage"`
+	DataType     string `json:"DataType"`
+	QueryInvalid string `json:"Query_Invalid,omitempty"`
+	QueryUpload  string `json:"Query_Upload,omitempty"`
+	FormatType   string `json:"FormatType"`
+}
+
+// GetInvalidRequest 查詢作廢發票請求
+type GetInvalidRequest struct {
+	RelateNumber string `json:"RelateNumber"`
+	InvoiceNo    string `json:"InvoiceNo"`
+	InvoiceDate  string `json:"InvoiceDate"` // yyyy-MM-dd
+}
+
+// Validate 驗證查詢作廢發票請求
+func (r *GetInvalidRequest) Validate() error {
+	if r.RelateNumber == "" {
+		return NewError(ErrCodeValidation, "RelateNumber 不能為空")
+	}
+	
+	if r.InvoiceNo == "" || r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "InvoiceNo 與 InvoiceDate 不能為空")
+	}
+	
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	return nil
+}
+
+// GetAllowanceListRequest 查詢折讓明細請求
+// 以 AllowanceNo 查詢單筆折讓，或以 InvoiceNo + InvoiceDate 查詢該發票所有折讓
+type GetAllowanceListRequest struct {
+	SearchType  string `json:"SearchType"`
+	AllowanceNo string `json:"AllowanceNo,omitempty"`
+	InvoiceNo   string `json:"InvoiceNo,omitempty"`
+	InvoiceDate string `json:"Date,omitempty"` // yyyy-MM-dd
+}
+
+// Validate 驗證查詢折讓明細請求，並依查詢條件設定 SearchType
+func (r *GetAllowanceListRequest) Validate() error {
+	if r.AllowanceNo != "" {
+		if !allowanceNoRegex.MatchString(r.AllowanceNo) {
+			return NewError(ErrCodeValidation, "AllowanceNo 格式不正確")
+		}
+		r.SearchType = AllowanceSearchByAllowanceNo
+		return nil
+	}
+	
+	if r.InvoiceNo == "" || r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "必須提供 AllowanceNo 或 InvoiceNo 與 InvoiceDate")
+	}
+	
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	r.SearchType = AllowanceSearchByInvoiceNo
+	return nil
+}
+
+// GetAllowanceInvalidRequest 查詢作廢折讓請求
+type GetAllowanceInvalidRequest struct {
+	InvoiceNo   string `json:"InvoiceNo"`
+	AllowanceNo string `json:"AllowanceNo"`
+}
+
+// Validate 驗證查詢作廢折讓請求
+func (r *GetAllowanceInvalidRequest) Validate() error {
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	if !allowanceNoRegex.MatchString(r.AllowanceNo) {
+		return NewError(ErrCodeValidation, "AllowanceNo 格式不正確")
+	}
+	
+	return nil
+}
+
+// InvoiceNotifyRequest 發送發票通知請求
+type InvoiceNotifyRequest struct {
+	InvoiceNo   string        `json:"InvoiceNo"`
+	AllowanceNo string        `json:"AllowanceNo,omitempty"` // 折讓相關通知時必填
+	Phone       string        `json:"Phone,omitempty"`
+	NotifyMail  string        `json:"NotifyMail,omitempty"`
+	Notify      NotifyChannel `json:"Notify"`
+	InvoiceTag  InvoiceTag    `json:"InvoiceTag"`
+	Notified    NotifyTarget  `json:"Notified"`
+}
+
+// Validate 驗證發送發票通知請求
+func (r *InvoiceNotifyRequest) Validate() error {
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	switch r.InvoiceTag {
+	case InvoiceTagIssue, InvoiceTagInvalid, InvoiceTagWinning:
+	case InvoiceTagAllowance, InvoiceTagAllowanceInvalid:
+		if !allowanceNoRegex.MatchString(r.AllowanceNo) {
+			return NewError(ErrCodeValidation, "折讓通知時 AllowanceNo 格式不正確")
+		}
+	default:
+		return NewError(ErrCodeValidation, "InvoiceTag 不正確")
+	}
+	
+	switch r.Notified {
+	case NotifyTargetCustomer, NotifyTargetMerchant, NotifyTargetAll:
+	default:
+		return NewError(ErrCodeValidation, "Notified 不正確")
+	}
+	
+	// 驗證通知方式與聯絡資訊
+	needPhone := r.Notify == NotifyChannelSMS || r.Notify == NotifyChannelAll
+	needMail := r.Notify == NotifyChannelEmail || r.Notify == NotifyChannelAll
+	if !needPhone && !needMail {
+		return NewError(ErrCodeValidation, "Notify 不正確")
+	}
+	
+	if needPhone && !phoneRegex.MatchString(r.Phone) {
+		return NewError(ErrCodeValidation, "簡訊通知時手機號碼格式不正確")
+	}
+	
+	if needMail && !emailRegex.MatchString(r.NotifyMail) {
+		return NewError(ErrCodeValidation, "電子郵件通知時 Email 格式不正確")
+	}
+	
+	return nil
+}
+
+// InvoiceNotifyResponse 發送發票通知回應
+type InvoiceNotifyResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+}
+
+// InvoicePrintRequest 發票列印請求
+type InvoicePrintRequest struct {
+	InvoiceNo       string `json:"InvoiceNo"`
+	InvoiceDate     string `json:"InvoiceDate"` // yyyy-MM-dd
+	PrintStyle      string `json:"PrintStyle"`
+	IsShowingDetail string `json:"IsShowingDetail,omitempty"`
+}
+
+// Validate 驗證發票列印請求
+func (r *InvoicePrintRequest) Validate() error {
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	if r.InvoiceDate == "" {
+		return NewError(ErrCodeValidation, "InvoiceDate 不能為空")
+	}
+	
+	switch r.PrintStyle {
+	case PrintStyleSingle, PrintStyleDuplicate, PrintStyleA4, PrintStyleReceipt:
+	default:
+		return NewError(ErrCodeValidation, "PrintStyle 不正確")
+	}
+	
+	switch r.IsShowingDetail {
+	case "", ShowingDetailYes, ShowingDetailNo:
+	default:
+		return NewError(ErrCodeValidation, "IsShowingDetail 不正確")
+	}
+	
+	return nil
+}
+
+// InvoicePrintResponse 發票列印回應
+type InvoicePrintResponse struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	InvoiceHtml string `json:"InvoiceHtml"` // 發票列印網址
+}
+
+// VoidWithReIssueRequest 作廢重開發票請求
+// 綠界會在同一次呼叫中作廢原發票並以相同發票號碼重新開立
+type VoidWithReIssueRequest struct {
+	Invalid *InvalidInvoiceRequest
+	Issue   *IssueInvoiceRequest
+}
+
+// Validate 驗證作廢重開發票請求
+func (r *VoidWithReIssueRequest) Validate() error {
+	if r.Invalid == nil || r.Issue == nil {
+		return NewError(ErrCodeValidation, "Invalid 與 Issue 不能為空")
+	}
+	
+	if err := r.Invalid.Validate(); err != nil {
+		return err
+	}
+	
+	return r.Issue.Validate()
+}
+
+// voidWithReIssueRequest 作廢重開發票 API 請求格式
+type voidWithReIssueRequest struct {
+	VoidModel struct {
+		InvoiceNo  string `json:"InvoiceNo"`
+		VoidReason string `json:"VoidReason"`
+	} `json:"VoidModel"`
+	IssueModel struct {
+		*IssueInvoiceRequest
+		InvoiceDate string `json:"InvoiceDate"` // 原發票開立日期
+	} `json:"IssueModel"`
+}
+
+// VoidWithReIssueResponse 作廢重開發票回應
+// 綠界以同一組 RtnCode 回報兩個步驟，作廢與重開同時成功或同時失敗；
+// Invalid 為原發票的作廢結果，Issue 為重新開立的發票
+type VoidWithReIssueResponse struct {
+	Invalid InvalidInvoiceResponse
+	Issue   IssueInvoiceResponse
+}
+
+// checkBarcodeRequest 手機條碼驗證請求
+type checkBarcodeRequest struct {
+	BarCode string `json:"BarCode"`
+}
+
+// checkLoveCodeRequest 捐贈碼驗證請求
+type checkLoveCodeRequest struct {
+	LoveCode string `json:"LoveCode"`
+}
+
+// checkCodeResponse 手機條碼/捐贈碼驗證回應
+type checkCodeResponse struct {
+	RtnCode int    `json:"RtnCode"`
+	RtnMsg  string `json:"RtnMsg"`
+	IsExist string `json:"IsExist"` // Y: 存在 N: 不存在
+}
+
+// getCompanyNameRequest 統一編號查詢公司名稱請求
+type getCompanyNameRequest struct {
+	UnifiedBusinessNo string `json:"UnifiedBusinessNo"`
+}
+
+// getCompanyNameResponse 統一編號查詢公司名稱回應
+type getCompanyNameResponse struct {
+	RtnCode     int    `json:"RtnCode"`
+	RtnMsg      string `json:"RtnMsg"`
+	CompanyName string `json:"CompanyName"`
+}
+
+// OfflineIssueRequest 上傳離線開立發票請求
+// 發票號碼、日期與隨機碼由 POS 離線時自行配置
+type OfflineIssueRequest struct {
+	IssueInvoiceRequest
+	InvoiceNo    string `json:"InvoiceNo"`
+	InvoiceDate  string `json:"InvoiceDate"` // yyyy-MM-dd HH:mm:ss
+	RandomNumber string `json:"RandomNumber"`
+}
+
+// Validate 驗證上傳離線開立發票請求
+func (r *OfflineIssueRequest) Validate() error {
+	if err := r.IssueInvoiceRequest.Validate(); err != nil {
+		return err
+	}
+	
+	if !invoiceNoRegex.MatchString(r.InvoiceNo) {
+		return NewError(ErrCodeValidation, "InvoiceNo 格式不正確")
+	}
+	
+	if _, err := ParseInvoiceDate(r.InvoiceDate); err != nil {
+		return NewError(ErrCodeValidation, "InvoiceDate 格式不正確")
+	}
+	
+	if !randomNumberRegex.MatchString(r.RandomNumber) {
+		return NewError(ErrCodeValidation, "RandomNumber 必須為 4 碼數字")
+	}
+	
+	return nil
 }
\ No newline at end of file
@@ -3,6 +3,9 @@ package ecpay
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,11 +15,61 @@ func GenerateRelateNumber(prefix string) string {
 	return fmt.Sprintf("%s%d", prefix, timestamp)
 }
 
+// invoiceDateLayouts 綠界回傳的日期格式
+var invoiceDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+}
+
 // ParseInvoiceDate 解析發票日期
 func ParseInvoiceDate(dateStr string) (time.Time, error) {
-	// 綠界回傳格式: 2024-01-01 12:00:00
-	layout := "2006-01-02 15:04:05"
-	return time.Parse(layout, dateStr)
+	// 綠界回傳格式: 2024-01-01 12:00:00，部分 API 以 / 分隔或不含時間
+	var firstErr error
+	for _, layout := range invoiceDateLayouts {
+		t, err := time.Parse(layout, dateStr)
+		if err == nil {
+			return t, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// parseOptionalDate 解析可能為空的日期，空字串回傳零值
+func parseOptionalDate(dateStr string) (time.Time, error) {
+	dateStr = strings.TrimSpace(dateStr)
+	if dateStr == "" {
+		return time.Time{}, nil
+	}
+	return ParseInvoiceDate(dateStr)
+}
+
+// flexNumber 綠界回傳的數字欄位，可能是 number、字串或空字串
+type flexNumber float64
+
+// UnmarshalJSON 實作 json.Unmarshaler
+func (n *flexNumber) UnmarshalJSON(b []byte) error {
+	str := strings.Trim(strings.TrimSpace(string(b)), `"`)
+	if str == "" || str == "null" {
+		*n = 0
+		return nil
+	}
+	
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return fmt.Errorf("數字格式錯誤: %s", str)
+	}
+	*n = flexNumber(v)
+	return nil
+}
+
+// Int 轉換為整數金額（四捨五入）
+func (n flexNumber) Int() int {
+	return int(math.Round(float64(n)))
 }
 
 // FormatInvoiceDate 格式化發票日期